@@ -6,10 +6,6 @@ terraform {
   }
 }
 
-provider "lambdabased" {
-  region = "us-east-1"
-}
-
 locals {
   credentials = {
     creds = "temporary-creds" # For instance, this can a token acquired with aws_eks_cluster_auth
@@ -24,16 +20,21 @@ locals {
   }
 }
 
+provider "lambdabased" {
+  region = "us-east-1"
+  default_secret_input = jsonencode(local.credentials) # merged under every input, including finalizers
+}
+
 resource "lambdabased_resource" "test" {
     function_name = "test-function"
     triggers = {
       param = sha512(jsonencode(local.parameters)) # drop sha512 if you want to store this in cleartext in the tf state
     }
-    input = jsonencode(merge(local.credentials, local.parameters))
+    input = jsonencode(local.parameters)
     conceal_input = true
     conceal_result = true
     finalizer {
         function_name = "test-function" # or another function if needed
-        input = jsonencode(merge(local.credentials, local.destroy_parameters))
+        input = jsonencode(local.destroy_parameters)
     }
 }
@@ -0,0 +1,323 @@
+// Package lambdabasedenc decrypts the inputs the lambdabased provider encrypts
+// when a resource has an input_encryption block, so that the cleartext never
+// passes through the Lambda service or any logging in between.
+//
+// The provider generates a data key with KMS for every invocation and encrypts
+// either the whole payload or the selected fields with AES-256-GCM. The data
+// key, wrapped by KMS, travels in the payload:
+//
+//	{
+//	  "password": {"lambdabased_encrypted": "<base64 nonce and ciphertext>"},
+//	  "user": "admin",
+//	  "lambdabased_encryption": {"kms_key_id": "...", "encrypted_key": "<base64>", "algorithm": "AES-256-GCM"}
+//	}
+//
+// Handlers restore the original payload with Decrypt, which needs kms:Decrypt
+// permission on the key:
+//
+//	func handler(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+//		payload, err := lambdabasedenc.Decrypt(ctx, kms.NewFromConfig(cfg), payload)
+//		...
+//	}
+//
+// LocalKMS stands in for KMS in tests.
+package lambdabasedenc
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// Names of the payload fields added by the encryption.
+const (
+	// FieldEncryption is the top-level field describing the encryption.
+	FieldEncryption = "lambdabased_encryption"
+	// FieldEncrypted replaces an encrypted value, or the whole payload.
+	FieldEncrypted = "lambdabased_encrypted"
+)
+
+// AlgorithmAES256GCM is the only supported algorithm.
+const AlgorithmAES256GCM = "AES-256-GCM"
+
+// ErrDecryptionFailed is returned when a ciphertext doesn't decrypt with the
+// data key, e.g. because it was tampered with or moved to another field.
+var ErrDecryptionFailed = errors.New("decryption failed")
+
+// KMSClient is the subset of the KMS API used to wrap and unwrap data keys.
+// *kms.Client implements it.
+type KMSClient interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// Encryption describes how the payload is encrypted.
+type Encryption struct {
+	KMSKeyID     string `json:"kms_key_id"`
+	EncryptedKey []byte `json:"encrypted_key"`
+	Algorithm    string `json:"algorithm"`
+}
+
+// Encrypt encrypts the fields of the JSON payload with a new data key of the
+// KMS key. Fields are dot-separated paths, numeric segments index arrays. If no
+// field is given, the whole payload is encrypted.
+func Encrypt(ctx context.Context, client KMSClient, kmsKeyID string, payload []byte, fields []string) ([]byte, error) {
+	dataKey, err := client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(kmsKeyID),
+		KeySpec: kmstypes.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generating data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]interface{}{}
+	if len(fields) == 0 {
+		if document[FieldEncrypted], err = seal(aead, "", payload); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := decodeJSON(payload, &document); err != nil {
+			return nil, fmt.Errorf("payload is not a JSON object: %w", err)
+		}
+		for _, field := range fields {
+			if err := encryptField(aead, document, field); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if keyID := aws.ToString(dataKey.KeyId); keyID != "" {
+		kmsKeyID = keyID
+	}
+	document[FieldEncryption] = Encryption{
+		KMSKeyID:     kmsKeyID,
+		EncryptedKey: dataKey.CiphertextBlob,
+		Algorithm:    AlgorithmAES256GCM,
+	}
+	return encodeJSON(document)
+}
+
+// Decrypt returns the payload as it was before Encrypt. Payloads that aren't
+// encrypted are returned as is. Payloads with encrypted fields are returned in
+// their canonical form, see Canonicalize.
+func Decrypt(ctx context.Context, client KMSClient, payload []byte) ([]byte, error) {
+	var document map[string]interface{}
+	if decodeJSON(payload, &document) != nil || document[FieldEncryption] == nil {
+		return payload, nil
+	}
+
+	raw, err := json.Marshal(document[FieldEncryption])
+	if err != nil {
+		return nil, err
+	}
+	var encryption Encryption
+	if err := json.Unmarshal(raw, &encryption); err != nil {
+		return nil, fmt.Errorf("%s: %w", FieldEncryption, err)
+	}
+	if encryption.Algorithm != AlgorithmAES256GCM {
+		return nil, fmt.Errorf("%s: unsupported algorithm %q", FieldEncryption, encryption.Algorithm)
+	}
+	delete(document, FieldEncryption)
+
+	dataKey, err := client.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob: encryption.EncryptedKey,
+		KeyId:          aws.String(encryption.KMSKeyID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decrypting data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	// The whole payload was encrypted
+	if sealed, ok := encryptedValue(document); ok {
+		return open(aead, "", sealed)
+	}
+	if _, err := decryptValue(aead, "", document); err != nil {
+		return nil, err
+	}
+	return encodeJSON(document)
+}
+
+// Canonicalize returns the JSON payload in the form Decrypt restores payloads
+// with encrypted fields to: object keys sorted, no insignificant whitespace and
+// no HTML escaping. Numbers are kept as they are written. The provider signs
+// the canonical payload when it encrypts fields, so that the signature can be
+// verified over the output of Decrypt.
+func Canonicalize(payload []byte) ([]byte, error) {
+	var v interface{}
+	if err := decodeJSON(payload, &v); err != nil {
+		return nil, err
+	}
+	return encodeJSON(v)
+}
+
+func encryptField(aead cipher.AEAD, document map[string]interface{}, field string) error {
+	segments := strings.Split(field, ".")
+	var parent interface{} = document
+	for i, segment := range segments {
+		value, ok := child(parent, segment)
+		if !ok {
+			return fmt.Errorf("field %s: not found in the payload", field)
+		}
+		if i < len(segments)-1 {
+			parent = value
+			continue
+		}
+
+		plaintext, err := encodeJSON(value)
+		if err != nil {
+			return err
+		}
+		sealed, err := seal(aead, field, plaintext)
+		if err != nil {
+			return err
+		}
+		setChild(parent, segment, map[string]interface{}{FieldEncrypted: sealed})
+	}
+	return nil
+}
+
+// decryptValue replaces the encrypted values in v, whose path is path, with
+// their plaintext and returns v.
+func decryptValue(aead cipher.AEAD, path string, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if sealed, ok := encryptedValue(v); ok {
+			plaintext, err := open(aead, path, sealed)
+			if err != nil {
+				return nil, err
+			}
+			var ret interface{}
+			if err := decodeJSON(plaintext, &ret); err != nil {
+				return nil, fmt.Errorf("%s: %w", describePath(path), err)
+			}
+			return ret, nil
+		}
+		for k, c := range v {
+			decrypted, err := decryptValue(aead, joinPath(path, k), c)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = decrypted
+		}
+	case []interface{}:
+		for i, c := range v {
+			decrypted, err := decryptValue(aead, joinPath(path, strconv.Itoa(i)), c)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = decrypted
+		}
+	}
+	return v, nil
+}
+
+func encryptedValue(v map[string]interface{}) (string, bool) {
+	sealed, ok := v[FieldEncrypted].(string)
+	return sealed, ok && len(v) == 1
+}
+
+func child(parent interface{}, segment string) (interface{}, bool) {
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		v, ok := parent[segment]
+		return v, ok
+	case []interface{}:
+		i, err := strconv.Atoi(segment)
+		if err != nil || i < 0 || i >= len(parent) {
+			return nil, false
+		}
+		return parent[i], true
+	}
+	return nil, false
+}
+
+func setChild(parent interface{}, segment string, v interface{}) {
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		parent[segment] = v
+	case []interface{}:
+		i, _ := strconv.Atoi(segment)
+		parent[i] = v
+	}
+}
+
+func joinPath(path, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext bound to its path, so that encrypted values can't be
+// moved to other fields.
+func seal(aead cipher.AEAD, path string, plaintext []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generating nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, []byte(path))), nil
+}
+
+func open(aead cipher.AEAD, path, sealed string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return nil, fmt.Errorf("%s: malformed ciphertext", describePath(path))
+	}
+	plaintext, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], []byte(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", describePath(path), ErrDecryptionFailed)
+	}
+	return plaintext, nil
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return "payload"
+	}
+	return "field " + path
+}
+
+// decodeJSON keeps numbers as they are written.
+func decodeJSON(data []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+func encodeJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,112 @@
+package lambdabasedenc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+	localKMS := NewLocalKMS("alias/terraform")
+	payload := []byte(`{"user":"admin","password":"s3cr3t","nested":{"tokens":["a",{"id":1.50}]}}`)
+
+	encrypted, err := Encrypt(ctx, localKMS, "alias/terraform", payload, []string{"password", "nested.tokens.1"})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "s3cr3t")
+	var document map[string]interface{}
+	assert.NoError(t, json.Unmarshal(encrypted, &document))
+	assert.Equal(t, "admin", document["user"])
+	assert.Contains(t, document["password"], FieldEncrypted)
+
+	decrypted, err := Decrypt(ctx, localKMS, encrypted)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(payload), string(decrypted))
+	assert.Contains(t, string(decrypted), "1.50")
+
+	encrypted, err = Encrypt(ctx, localKMS, "alias/terraform", payload, nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "admin")
+	decrypted, err = Decrypt(ctx, localKMS, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, string(payload), string(decrypted))
+
+	// Not encrypted payloads are passed through
+	decrypted, err = Decrypt(ctx, localKMS, payload)
+	assert.NoError(t, err)
+	assert.Equal(t, string(payload), string(decrypted))
+
+	_, err = Encrypt(ctx, localKMS, "alias/terraform", payload, []string{"nested.missing"})
+	assert.EqualError(t, err, "field nested.missing: not found in the payload")
+
+	var notFound *kmstypes.NotFoundException
+	_, err = Encrypt(ctx, localKMS, "alias/other", payload, nil)
+	assert.True(t, errors.As(err, &notFound))
+}
+
+func TestCanonicalize(t *testing.T) {
+	ctx := context.Background()
+	localKMS := NewLocalKMS("alias/terraform")
+	payload := []byte(`{"user":"a<b", "password":"p", "x": 1.50}`)
+
+	canonical, err := Canonicalize(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"password":"p","user":"a<b","x":1.50}`, string(canonical))
+
+	// Decrypting payloads with encrypted fields restores the canonical form
+	encrypted, err := Encrypt(ctx, localKMS, "alias/terraform", payload, []string{"password"})
+	assert.NoError(t, err)
+	decrypted, err := Decrypt(ctx, localKMS, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, string(canonical), string(decrypted))
+
+	_, err = Canonicalize([]byte("not-json"))
+	assert.Error(t, err)
+}
+
+func TestDecrypt_movedValue(t *testing.T) {
+	ctx := context.Background()
+	localKMS := NewLocalKMS("alias/terraform")
+
+	encrypted, err := Encrypt(ctx, localKMS, "alias/terraform", []byte(`{"a":"x","b":"y"}`), []string{"a", "b"})
+	assert.NoError(t, err)
+	var document map[string]interface{}
+	assert.NoError(t, json.Unmarshal(encrypted, &document))
+	document["a"], document["b"] = document["b"], document["a"]
+	swapped, _ := json.Marshal(document)
+
+	_, err = Decrypt(ctx, localKMS, swapped)
+	assert.True(t, errors.Is(err, ErrDecryptionFailed))
+}
+
+func TestLocalKMS_serveHTTP(t *testing.T) {
+	ctx := context.Background()
+	localKMS := NewLocalKMS("alias/terraform")
+	server := httptest.NewServer(localKMS)
+	defer server.Close()
+
+	client := kms.New(kms.Options{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		EndpointResolver: kms.EndpointResolverFromURL(server.URL),
+	})
+
+	encrypted, err := Encrypt(ctx, client, "alias/terraform", []byte(`{"password":"s3cr3t"}`), []string{"password"})
+	assert.NoError(t, err)
+	decrypted, err := Decrypt(ctx, client, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"password":"s3cr3t"}`, string(decrypted))
+
+	var notFound *kmstypes.NotFoundException
+	_, err = Encrypt(ctx, client, "alias/other", encrypted, nil)
+	assert.True(t, errors.As(err, &notFound))
+	assert.True(t, strings.Contains(err.Error(), "alias/other"))
+}
@@ -0,0 +1,157 @@
+package lambdabasedenc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// LocalKMS is an in-memory stand-in for KMS. It implements KMSClient for unit
+// tests of handlers, and serves the GenerateDataKey and Decrypt operations of
+// the KMS JSON protocol so that the provider can be pointed at it with its
+// kms_endpoint argument:
+//
+//	localKMS := lambdabasedenc.NewLocalKMS("alias/terraform")
+//	server := httptest.NewServer(localKMS)
+//	defer server.Close()
+//
+// Keys only live as long as the LocalKMS.
+type LocalKMS struct {
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+// NewLocalKMS returns a LocalKMS with the given keys. Other key ids are
+// reported as not found.
+func NewLocalKMS(keyIDs ...string) *LocalKMS {
+	k := &LocalKMS{keys: map[string][]byte{}}
+	for _, keyID := range keyIDs {
+		k.CreateKey(keyID)
+	}
+	return k
+}
+
+// CreateKey adds a key with a random key material.
+func (k *LocalKMS) CreateKey(keyID string) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[keyID] = key
+}
+
+func (k *LocalKMS) key(keyID string) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, &kmstypes.NotFoundException{Message: aws.String(fmt.Sprintf("key %s does not exist", keyID))}
+	}
+	return key, nil
+}
+
+// GenerateDataKey returns a random 256-bit data key and the same key encrypted
+// with the KMS key.
+func (k *LocalKMS) GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	keyID := aws.ToString(params.KeyId)
+	key, err := k.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	sealed, err := seal(aead, keyID, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &kms.GenerateDataKeyOutput{
+		KeyId:          aws.String(keyID),
+		Plaintext:      dataKey,
+		CiphertextBlob: []byte(keyID + "\n" + sealed),
+	}, nil
+}
+
+// Decrypt decrypts data keys returned by GenerateDataKey.
+func (k *LocalKMS) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	parts := strings.SplitN(string(params.CiphertextBlob), "\n", 2)
+	if len(parts) != 2 {
+		return nil, &kmstypes.InvalidCiphertextException{Message: aws.String("malformed ciphertext")}
+	}
+	keyID := parts[0]
+	if params.KeyId != nil && aws.ToString(params.KeyId) != keyID {
+		return nil, &kmstypes.IncorrectKeyException{Message: aws.String(fmt.Sprintf("ciphertext was not encrypted with %s", aws.ToString(params.KeyId)))}
+	}
+	key, err := k.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := open(aead, keyID, parts[1])
+	if err != nil {
+		return nil, &kmstypes.InvalidCiphertextException{Message: aws.String(err.Error())}
+	}
+	return &kms.DecryptOutput{
+		KeyId:               aws.String(keyID),
+		Plaintext:           dataKey,
+		EncryptionAlgorithm: kmstypes.EncryptionAlgorithmSpecSymmetricDefault,
+	}, nil
+}
+
+// ServeHTTP implements the GenerateDataKey and Decrypt operations of the KMS
+// JSON protocol.
+func (k *LocalKMS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		res interface{}
+		err error
+	)
+	switch r.Header.Get("X-Amz-Target") {
+	case "TrentService.GenerateDataKey":
+		var params kms.GenerateDataKeyInput
+		if err = json.NewDecoder(r.Body).Decode(&params); err == nil {
+			res, err = k.GenerateDataKey(r.Context(), &params)
+		}
+	case "TrentService.Decrypt":
+		var params kms.DecryptInput
+		if err = json.NewDecoder(r.Body).Decode(&params); err == nil {
+			res, err = k.Decrypt(r.Context(), &params)
+		}
+	default:
+		err = &kmstypes.UnsupportedOperationException{Message: aws.String(fmt.Sprintf("%s is not supported", r.Header.Get("X-Amz-Target")))}
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	if err != nil {
+		code := "ValidationException"
+		if apiErr, ok := err.(interface{ ErrorCode() string }); ok {
+			code = apiErr.ErrorCode()
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"__type": code, "message": err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(res)
+	w.Write(buf.Bytes())
+}
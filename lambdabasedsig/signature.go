@@ -0,0 +1,212 @@
+// Package lambdabasedsig verifies the signatures the lambdabased provider adds
+// to its invocations when the provider's signing block is configured, so that
+// functions can reject payloads that weren't sent by Terraform.
+//
+// The signature is carried in the custom fields of the Lambda client context,
+// leaving the payload untouched:
+//
+//	func handler(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+//		lc, _ := lambdacontext.FromContext(ctx)
+//		if _, err := lambdabasedsig.Verify(lc.ClientContext.Custom, payload, keys, 5*time.Minute); err != nil {
+//			return nil, err
+//		}
+//		...
+//	}
+//
+// The http and function url backends send the encoded client context in the
+// X-Lambdabased-Client-Context header and the exec backend in the
+// LAMBDABASED_CLIENT_CONTEXT environment variable; ParseClientContext decodes it.
+package lambdabasedsig
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported signing algorithms.
+const (
+	AlgorithmHMACSHA256 = "HMAC-SHA256"
+	AlgorithmHMACSHA512 = "HMAC-SHA512"
+)
+
+// Names of the client context custom fields carrying the signature.
+const (
+	FieldKeyID     = "lambdabased-key-id"
+	FieldAlgorithm = "lambdabased-algorithm"
+	FieldTimestamp = "lambdabased-timestamp"
+	FieldNonce     = "lambdabased-nonce"
+	FieldSignature = "lambdabased-signature"
+)
+
+// MaxClientContextSize is the largest encoded client context AWS Lambda accepts.
+const MaxClientContextSize = 3583
+
+var (
+	// ErrMissingSignature is returned when the client context carries no signature.
+	ErrMissingSignature = errors.New("invocation is not signed")
+	// ErrUnknownKey is returned when the signature was made with a key id that
+	// isn't among the keys passed to Verify.
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrInvalidSignature is returned when the signature doesn't match the payload.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrExpired is returned when the signature is older, or further in the
+	// future, than the allowed age.
+	ErrExpired = errors.New("signature expired")
+)
+
+// Signature is the signature of a single invocation.
+type Signature struct {
+	KeyID     string
+	Algorithm string
+	Timestamp time.Time
+	// Nonce is unique to every invocation. Handlers that must not process the
+	// same invocation twice can remember the nonces seen within the allowed age.
+	Nonce string
+	// Value is the base64 encoded HMAC of the canonical string.
+	Value string
+}
+
+// Sign signs payload with key at the given time using a random nonce.
+func Sign(key []byte, keyID, algorithm string, payload []byte, now time.Time) (*Signature, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	s := &Signature{
+		KeyID:     keyID,
+		Algorithm: algorithm,
+		Timestamp: time.Unix(now.Unix(), 0),
+		Nonce:     hex.EncodeToString(nonce),
+	}
+	mac, err := s.compute(key, payload)
+	if err != nil {
+		return nil, err
+	}
+	s.Value = base64.StdEncoding.EncodeToString(mac)
+	return s, nil
+}
+
+// Custom returns the client context custom fields carrying the signature.
+func (s *Signature) Custom() map[string]string {
+	return map[string]string{
+		FieldKeyID:     s.KeyID,
+		FieldAlgorithm: s.Algorithm,
+		FieldTimestamp: strconv.FormatInt(s.Timestamp.Unix(), 10),
+		FieldNonce:     s.Nonce,
+		FieldSignature: s.Value,
+	}
+}
+
+// ClientContext returns the base64 encoded client context carrying the
+// signature, as expected by the Lambda Invoke API.
+func (s *Signature) ClientContext() (string, error) {
+	raw, err := json.Marshal(map[string]interface{}{"custom": s.Custom()})
+	if err != nil {
+		return "", err
+	}
+	ret := base64.StdEncoding.EncodeToString(raw)
+	if len(ret) > MaxClientContextSize {
+		return "", fmt.Errorf("client context is %d bytes, longer than %d", len(ret), MaxClientContextSize)
+	}
+	return ret, nil
+}
+
+// ParseClientContext decodes a base64 encoded client context and returns its
+// custom fields.
+func ParseClientContext(encoded string) (map[string]string, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoding client context: %w", err)
+	}
+	var clientContext struct {
+		Custom map[string]string `json:"custom"`
+	}
+	if err := json.Unmarshal(raw, &clientContext); err != nil {
+		return nil, fmt.Errorf("decoding client context: %w", err)
+	}
+	return clientContext.Custom, nil
+}
+
+// Parse reads the signature from the client context custom fields.
+func Parse(custom map[string]string) (*Signature, error) {
+	if custom[FieldSignature] == "" {
+		return nil, ErrMissingSignature
+	}
+	seconds, err := strconv.ParseInt(custom[FieldTimestamp], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", FieldTimestamp, err)
+	}
+	return &Signature{
+		KeyID:     custom[FieldKeyID],
+		Algorithm: custom[FieldAlgorithm],
+		Timestamp: time.Unix(seconds, 0),
+		Nonce:     custom[FieldNonce],
+		Value:     custom[FieldSignature],
+	}, nil
+}
+
+// Verify checks that the signature in the client context custom fields was made
+// over payload with one of keys, which are looked up by key id, and that it is
+// not older than maxAge. Clock skew is tolerated up to maxAge as well.
+func Verify(custom map[string]string, payload []byte, keys map[string][]byte, maxAge time.Duration) (*Signature, error) {
+	s, err := Parse(custom)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := keys[s.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, s.KeyID)
+	}
+	expected, err := s.compute(key, payload)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := base64.StdEncoding.DecodeString(s.Value)
+	if err != nil || !hmac.Equal(expected, actual) {
+		return nil, ErrInvalidSignature
+	}
+	if age := time.Since(s.Timestamp); age > maxAge || age < -maxAge {
+		return nil, fmt.Errorf("%w: signed at %s", ErrExpired, s.Timestamp.UTC().Format(time.RFC3339))
+	}
+	return s, nil
+}
+
+func (s *Signature) compute(key, payload []byte) ([]byte, error) {
+	var h func() hash.Hash
+	switch s.Algorithm {
+	case AlgorithmHMACSHA256:
+		h = sha256.New
+	case AlgorithmHMACSHA512:
+		h = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", s.Algorithm)
+	}
+	mac := hmac.New(h, key)
+	mac.Write([]byte(s.canonical(payload)))
+	return mac.Sum(nil), nil
+}
+
+// canonical returns the string that is signed. The payload is included by its
+// SHA-256 hash.
+func (s *Signature) canonical(payload []byte) string {
+	payloadHash := sha256.Sum256(payload)
+	return strings.Join([]string{
+		"lambdabased-v1",
+		s.Algorithm,
+		s.KeyID,
+		strconv.FormatInt(s.Timestamp.Unix(), 10),
+		s.Nonce,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+}
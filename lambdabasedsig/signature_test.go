@@ -0,0 +1,61 @@
+package lambdabasedsig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	keys := map[string][]byte{"k1": []byte("secret-1"), "k2": []byte("secret-2")}
+	payload := []byte(`{"name":"my-resource"}`)
+
+	for _, algorithm := range []string{AlgorithmHMACSHA256, AlgorithmHMACSHA512} {
+		s, err := Sign(keys["k2"], "k2", algorithm, payload, time.Now())
+		assert.NoError(t, err)
+
+		encoded, err := s.ClientContext()
+		assert.NoError(t, err)
+		custom, err := ParseClientContext(encoded)
+		assert.NoError(t, err)
+
+		verified, err := Verify(custom, payload, keys, time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, s.Nonce, verified.Nonce)
+	}
+
+	s, _ := Sign(keys["k1"], "k1", AlgorithmHMACSHA256, payload, time.Now())
+	other, _ := Sign(keys["k1"], "k1", AlgorithmHMACSHA256, payload, time.Now())
+	assert.NotEqual(t, s.Nonce, other.Nonce)
+
+	_, err := Verify(s.Custom(), []byte(`{"name":"other"}`), keys, time.Minute)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	_, err = Verify(s.Custom(), payload, map[string][]byte{"k1": []byte("wrong")}, time.Minute)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	_, err = Verify(s.Custom(), payload, map[string][]byte{"k2": keys["k2"]}, time.Minute)
+	assert.True(t, errors.Is(err, ErrUnknownKey))
+
+	tampered := s.Custom()
+	tampered[FieldTimestamp] = "1"
+	_, err = Verify(tampered, payload, keys, time.Minute)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	old, _ := Sign(keys["k1"], "k1", AlgorithmHMACSHA256, payload, time.Now().Add(-time.Hour))
+	_, err = Verify(old.Custom(), payload, keys, time.Minute)
+	assert.True(t, errors.Is(err, ErrExpired))
+
+	_, err = Verify(map[string]string{}, payload, keys, time.Minute)
+	assert.True(t, errors.Is(err, ErrMissingSignature))
+
+	_, err = Sign(keys["k1"], "k1", "MD5", payload, time.Now())
+	assert.Error(t, err)
+
+	long, _ := Sign(keys["k1"], strings.Repeat("k", MaxClientContextSize), AlgorithmHMACSHA256, payload, time.Now())
+	_, err = long.ClientContext()
+	assert.Error(t, err)
+}
@@ -0,0 +1,241 @@
+// Package lambdabasedtest provides an in-memory stand-in for AWS Lambda so that
+// configurations built on top of the lambdabased provider can be tested with
+// resource.Test without AWS access.
+//
+//	fake := lambdabasedtest.NewFake()
+//	fake.Register("install-chart", "", func(ctx context.Context, payload []byte) ([]byte, error) {
+//		return []byte(`{"release":"my-release"}`), nil
+//	})
+//
+//	resource.Test(t, resource.TestCase{
+//		ProviderFactories: fake.ProviderFactories(),
+//		...
+//	})
+//
+//	fake.AssertInvoked(t, "install-chart", 1)
+package lambdabasedtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/thetradedesk/terraform-provider-lambdabased/provider"
+)
+
+// HandlerFunc implements a fake function. The returned payload is the result
+// of the invocation; an error is reported as a function error, like an unhandled
+// exception in a real Lambda function.
+type HandlerFunc func(ctx context.Context, payload []byte) ([]byte, error)
+
+// Fault is an error condition that can be injected into invocations.
+type Fault int
+
+const (
+	// Throttle fails the invocation with a TooManyRequestsException.
+	Throttle Fault = iota
+	// FunctionError makes the function return an unhandled error.
+	FunctionError
+	// Timeout makes the function time out.
+	Timeout
+)
+
+// Invocation is a past invocation of the fake.
+type Invocation struct {
+	FunctionName   string
+	Qualifier      string
+	InvocationType lambdatypes.InvocationType
+	Payload        []byte
+	Result         []byte
+	FunctionError  string
+	Err            error
+}
+
+type functionKey struct {
+	name      string
+	qualifier string
+}
+
+// Fake is an in-memory function registry implementing provider.LambdaClient.
+// Functions are keyed by their name and qualifier; function urls and state
+// machine ARNs are used as names. It is safe for concurrent use.
+type Fake struct {
+	mu          sync.Mutex
+	functions   map[functionKey]HandlerFunc
+	faults      map[string][]Fault
+	invocations []Invocation
+}
+
+var _ provider.LambdaClient = (*Fake)(nil)
+
+// NewFake returns a Fake without any registered function.
+func NewFake() *Fake {
+	return &Fake{
+		functions: map[functionKey]HandlerFunc{},
+		faults:    map[string][]Fault{},
+	}
+}
+
+// Register adds a function to the registry. An empty qualifier matches any
+// qualifier that isn't registered explicitly.
+func (f *Fake) Register(name, qualifier string, handler HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.functions[functionKey{name, qualifier}] = handler
+}
+
+// Inject makes the next invocations of the function fail with the given faults,
+// one fault per invocation in the given order.
+func (f *Fake) Inject(name string, faults ...Fault) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.faults[name] = append(f.faults[name], faults...)
+}
+
+// ProviderFactories returns provider factories for resource.TestCase wired to the fake.
+func (f *Fake) ProviderFactories() map[string]func() (*schema.Provider, error) {
+	return map[string]func() (*schema.Provider, error){
+		"lambdabased": func() (*schema.Provider, error) {
+			return provider.ProviderWithClient(f), nil
+		},
+	}
+}
+
+// Invoke runs the handler registered for the function name and qualifier,
+// falling back to the one registered without a qualifier, and records the
+// invocation. Unknown functions fail with a ResourceNotFoundException, and
+// injected faults are applied before the handler is called.
+func (f *Fake) Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	inv := Invocation{
+		FunctionName:   aws.ToString(params.FunctionName),
+		Qualifier:      aws.ToString(params.Qualifier),
+		InvocationType: params.InvocationType,
+		Payload:        params.Payload,
+	}
+	res, err := f.invoke(ctx, inv)
+	if err != nil {
+		inv.Err = err
+	} else {
+		inv.Result = res.Payload
+		inv.FunctionError = aws.ToString(res.FunctionError)
+	}
+
+	f.mu.Lock()
+	f.invocations = append(f.invocations, inv)
+	f.mu.Unlock()
+	return res, err
+}
+
+func (f *Fake) invoke(ctx context.Context, inv Invocation) (*lambda.InvokeOutput, error) {
+	f.mu.Lock()
+	handler, ok := f.functions[functionKey{inv.FunctionName, inv.Qualifier}]
+	if !ok {
+		handler, ok = f.functions[functionKey{inv.FunctionName, ""}]
+	}
+	var fault *Fault
+	if faults := f.faults[inv.FunctionName]; len(faults) > 0 {
+		fault = &faults[0]
+		f.faults[inv.FunctionName] = faults[1:]
+	}
+	f.mu.Unlock()
+
+	if !ok {
+		return nil, &lambdatypes.ResourceNotFoundException{
+			Message: aws.String(fmt.Sprintf("Function not found: %s:%s", inv.FunctionName, inv.Qualifier)),
+		}
+	}
+
+	if fault != nil {
+		switch *fault {
+		case Throttle:
+			return nil, &lambdatypes.TooManyRequestsException{Message: aws.String("Rate Exceeded.")}
+		case FunctionError:
+			return unhandled("InjectedError", "injected function error"), nil
+		case Timeout:
+			return unhandled("Sandbox.Timedout", "Task timed out"), nil
+		}
+	}
+
+	if inv.InvocationType == lambdatypes.InvocationTypeDryRun {
+		return &lambda.InvokeOutput{StatusCode: 204}, nil
+	}
+
+	res, err := handler(ctx, inv.Payload)
+	if err != nil {
+		return unhandled(fmt.Sprintf("%T", err), err.Error()), nil
+	}
+	return &lambda.InvokeOutput{StatusCode: 200, Payload: res}, nil
+}
+
+func unhandled(errorType, message string) *lambda.InvokeOutput {
+	payload, _ := json.Marshal(map[string]string{"errorType": errorType, "errorMessage": message})
+	return &lambda.InvokeOutput{
+		StatusCode:    200,
+		FunctionError: aws.String("Unhandled"),
+		Payload:       payload,
+	}
+}
+
+// Invocations returns all invocations so far, including the failed ones.
+func (f *Fake) Invocations() []Invocation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Invocation(nil), f.invocations...)
+}
+
+// InvocationsOf returns the invocations of the given function.
+func (f *Fake) InvocationsOf(name string) []Invocation {
+	var ret []Invocation
+	for _, inv := range f.Invocations() {
+		if inv.FunctionName == name {
+			ret = append(ret, inv)
+		}
+	}
+	return ret
+}
+
+// TestingT is the subset of testing.TB used by the assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertInvoked checks that the function was invoked the given number of times.
+func (f *Fake) AssertInvoked(t TestingT, name string, times int) bool {
+	t.Helper()
+	if got := len(f.InvocationsOf(name)); got != times {
+		t.Errorf("expected %s to be invoked %d time(s), it was invoked %d time(s)", name, times, got)
+		return false
+	}
+	return true
+}
+
+// AssertInvokedWith checks that the function was invoked at least once with a
+// payload equal to the given JSON document.
+func (f *Fake) AssertInvokedWith(t TestingT, name string, payload string) bool {
+	t.Helper()
+	for _, inv := range f.InvocationsOf(name) {
+		if jsonEqual(inv.Payload, []byte(payload)) {
+			return true
+		}
+	}
+	t.Errorf("expected %s to be invoked with %s", name, payload)
+	return false
+}
+
+func jsonEqual(a, b []byte) bool {
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(a, b)
+	}
+	xs, _ := json.Marshal(x)
+	ys, _ := json.Marshal(y)
+	return bytes.Equal(xs, ys)
+}
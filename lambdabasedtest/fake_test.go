@@ -0,0 +1,107 @@
+package lambdabasedtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+	resource "lambdabased_resource" "test" {
+		function_name = "install"
+		triggers = { version = "%s" }
+		input = jsonencode({ chart = "my-chart" })
+		finalizer {
+			function_name = "uninstall"
+			qualifier = "live"
+			input = jsonencode({ chart = "my-chart" })
+		}
+	}`
+
+func TestFake_lifecycle(t *testing.T) {
+	fake := NewFake()
+	fake.Register("install", "", func(ctx context.Context, payload []byte) ([]byte, error) {
+		return []byte(`{"release":"my-release"}`), nil
+	})
+	fake.Register("uninstall", "live", func(ctx context.Context, payload []byte) ([]byte, error) {
+		return nil, nil
+	})
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		ProviderFactories: fake.ProviderFactories(),
+		CheckDestroy: func(s *terraform.State) error {
+			fake.AssertInvoked(t, "uninstall", 1)
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testConfig, "1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lambdabased_resource.test", "result", `{"release":"my-release"}`),
+					func(s *terraform.State) error {
+						fake.AssertInvoked(t, "install", 1)
+						fake.AssertInvokedWith(t, "install", `{ "chart": "my-chart" }`)
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestFake_faults(t *testing.T) {
+	fake := NewFake()
+	fake.Register("install", "", func(ctx context.Context, payload []byte) ([]byte, error) {
+		return nil, errors.New("chart-not-found")
+	})
+	fake.Register("uninstall", "", func(ctx context.Context, payload []byte) ([]byte, error) {
+		return nil, nil
+	})
+	fake.Inject("install", Throttle, Timeout)
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		ProviderFactories: fake.ProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(testConfig, "1"),
+				ExpectError: regexp.MustCompile("TooManyRequestsException"),
+			},
+			{
+				Config:      fmt.Sprintf(testConfig, "1"),
+				ExpectError: regexp.MustCompile("Task timed out"),
+			},
+			{
+				Config:      fmt.Sprintf(testConfig, "1"),
+				ExpectError: regexp.MustCompile("chart-not-found"),
+			},
+		},
+	})
+
+	invocations := fake.InvocationsOf("install")
+	assert.Len(t, invocations, 3)
+	assert.Error(t, invocations[0].Err)
+	assert.Equal(t, "Unhandled", invocations[2].FunctionError)
+}
+
+func TestFake_unknownFunction(t *testing.T) {
+	fake := NewFake()
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		ProviderFactories: fake.ProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(testConfig, "1"),
+				ExpectError: regexp.MustCompile("Function not found: install"),
+			},
+		},
+	})
+}
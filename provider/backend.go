@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// LambdaClient is the backend every invocation goes through. Besides AWS Lambda
+// itself, it is implemented by the http and exec backends which translate the
+// InvokeInput into their own transport. A backend reports errors raised by the
+// function itself through InvokeOutput.FunctionError, like Lambda does.
+type LambdaClient interface {
+	Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error)
+}
+
+const (
+	backendAWSLambda = "aws_lambda"
+	backendHTTP      = "http"
+	backendExec      = "exec"
+)
+
+// newBackend builds the client of the configured backend. It returns nil for
+// aws_lambda since that client is built lazily from the AWS configuration.
+func newBackend(d *schema.ResourceData) (LambdaClient, error) {
+	switch backend := d.Get("backend").(string); backend {
+	case backendAWSLambda:
+		return nil, nil
+	case backendHTTP:
+		httpBackendRaw, ok := d.GetOk("http_backend")
+		if !ok {
+			return nil, fmt.Errorf("http_backend block is required when backend is %q", backend)
+		}
+		httpClient, err := buildHTTPClient(d)
+		if err != nil {
+			return nil, err
+		}
+		return newHTTPBackend(httpBackendRaw.([]interface{})[0].(map[string]interface{}), httpClient), nil
+	case backendExec:
+		execBackendRaw, ok := d.GetOk("exec_backend")
+		if !ok {
+			return nil, fmt.Errorf("exec_backend block is required when backend is %q", backend)
+		}
+		return newExecBackend(execBackendRaw.([]interface{})[0].(map[string]interface{})), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", backend)
+	}
+}
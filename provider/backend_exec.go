@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// execBackend runs a local command for every invocation. The payload is written
+// to its stdin and its stdout is the result. A non-zero exit code is reported
+// as a function error carrying stderr.
+type execBackend struct {
+	command []string
+	env     []string
+}
+
+func newExecBackend(cfg map[string]interface{}) *execBackend {
+	b := &execBackend{command: expandStringList(cfg["command"].([]interface{}))}
+	for k, v := range cfg["env"].(map[string]interface{}) {
+		b.env = append(b.env, fmt.Sprintf("%s=%s", k, v.(string)))
+	}
+	return b
+}
+
+func (b *execBackend) Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	// There is nothing to verify without running the command
+	if params.InvocationType == lambdatypes.InvocationTypeDryRun {
+		return &lambda.InvokeOutput{StatusCode: 204}, nil
+	}
+
+	cmd := exec.CommandContext(ctx, b.command[0], b.command[1:]...)
+	cmd.Env = append(os.Environ(), b.env...)
+	cmd.Env = append(cmd.Env,
+		"LAMBDABASED_FUNCTION_NAME="+aws.ToString(params.FunctionName),
+		"LAMBDABASED_QUALIFIER="+aws.ToString(params.Qualifier),
+	)
+	if params.ClientContext != nil {
+		cmd.Env = append(cmd.Env, "LAMBDABASED_CLIENT_CONTEXT="+aws.ToString(params.ClientContext))
+	}
+	cmd.Stdin = bytes.NewReader(params.Payload)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &lambda.InvokeOutput{
+			StatusCode:    200,
+			FunctionError: aws.String(exitErr.Error()),
+			Payload:       stderr.Bytes(),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lambda.InvokeOutput{
+		StatusCode: 200,
+		Payload:    stdout.Bytes(),
+	}, nil
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+const (
+	headerFunctionName = "X-Lambdabased-Function-Name"
+	headerQualifier    = "X-Lambdabased-Qualifier"
+)
+
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// httpBackend POSTs the payload to an HTTP endpoint. {function_name} and
+// {qualifier} in the url are replaced with the respective invocation parameters.
+type httpBackend struct {
+	url      string
+	headers  map[string]string
+	username string
+	password string
+	token    string
+	client   httpDoer
+}
+
+func newHTTPBackend(cfg map[string]interface{}, client httpDoer) *httpBackend {
+	b := &httpBackend{
+		url:     cfg["url"].(string),
+		headers: map[string]string{},
+		token:   cfg["bearer_token"].(string),
+		client:  client,
+	}
+	for k, v := range cfg["headers"].(map[string]interface{}) {
+		b.headers[k] = v.(string)
+	}
+	if basicAuth := cfg["basic_auth"].([]interface{}); len(basicAuth) > 0 {
+		b.username = basicAuth[0].(map[string]interface{})["username"].(string)
+		b.password = basicAuth[0].(map[string]interface{})["password"].(string)
+	}
+	return b
+}
+
+func (b *httpBackend) Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	// There is nothing to verify without calling the endpoint
+	if params.InvocationType == lambdatypes.InvocationTypeDryRun {
+		return &lambda.InvokeOutput{StatusCode: http.StatusNoContent}, nil
+	}
+
+	functionName := aws.ToString(params.FunctionName)
+	qualifier := aws.ToString(params.Qualifier)
+	url := strings.NewReplacer("{function_name}", functionName, "{qualifier}", qualifier).Replace(b.url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(params.Payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerFunctionName, functionName)
+	req.Header.Set(headerQualifier, qualifier)
+	if params.ClientContext != nil {
+		req.Header.Set(headerClientContext, aws.ToString(params.ClientContext))
+	}
+	for k, v := range b.headers {
+		req.Header.Set(k, v)
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	} else if b.username != "" {
+		req.SetBasicAuth(b.username, b.password)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response of %s: %w", url, err)
+	}
+
+	ret := &lambda.InvokeOutput{
+		StatusCode: int32(resp.StatusCode),
+		Payload:    body,
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		ret.FunctionError = aws.String(resp.Status)
+	}
+	return ret, nil
+}
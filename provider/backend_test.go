@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPBackend_invoke(t *testing.T) {
+	var clientContexts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		clientContexts = append(clientContexts, r.Header.Get(headerClientContext))
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/hooks/func-name", r.URL.Path)
+		assert.Equal(t, "func-name", r.Header.Get(headerFunctionName))
+		assert.Equal(t, "$LATEST", r.Header.Get(headerQualifier))
+		assert.Equal(t, "header-val", r.Header.Get("X-Custom"))
+		assert.Equal(t, "Bearer token-val", r.Header.Get("Authorization"))
+		if string(body) == `{"fail":true}` {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		w.Write([]byte("result-val"))
+	}))
+	defer server.Close()
+
+	b := newHTTPBackend(map[string]interface{}{
+		"url":          server.URL + "/hooks/{function_name}",
+		"headers":      map[string]interface{}{"X-Custom": "header-val"},
+		"bearer_token": "token-val",
+		"basic_auth":   []interface{}{},
+	}, server.Client())
+
+	res, err := b.Invoke(context.Background(), createInvokeInput(`{"param":"val"}`))
+	assert.NoError(t, err)
+	assert.Nil(t, res.FunctionError)
+	assert.Equal(t, "result-val", string(res.Payload))
+
+	input := createInvokeInput(`{"fail":true}`)
+	input.ClientContext = aws.String("client-context-val")
+	res, err = b.Invoke(context.Background(), input)
+	assert.NoError(t, err)
+	assert.Equal(t, "400 Bad Request", aws.ToString(res.FunctionError))
+	assert.Equal(t, "result-val", string(res.Payload))
+	assert.Equal(t, []string{"", "client-context-val"}, clientContexts)
+}
+
+func TestExecBackend_invoke(t *testing.T) {
+	b := newExecBackend(map[string]interface{}{
+		"command": []interface{}{"sh", "-c", `cat; echo " $LAMBDABASED_FUNCTION_NAME $CUSTOM_ENV"`},
+		"env":     map[string]interface{}{"CUSTOM_ENV": "env-val"},
+	})
+
+	res, err := b.Invoke(context.Background(), createInvokeInput(`{"param":"val"}`))
+	assert.NoError(t, err)
+	assert.Nil(t, res.FunctionError)
+	assert.Equal(t, "{\"param\":\"val\"} func-name env-val\n", string(res.Payload))
+
+	b = newExecBackend(map[string]interface{}{
+		"command": []interface{}{"sh", "-c", "echo this-error-is-expected >&2; exit 3"},
+		"env":     map[string]interface{}{},
+	})
+	res, err = b.Invoke(context.Background(), createInvokeInput(`{}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "exit status 3", aws.ToString(res.FunctionError))
+	assert.Equal(t, "this-error-is-expected\n", string(res.Payload))
+}
+
+func TestLambdaBasedResource_execBackend(t *testing.T) {
+	dir := t.TempDir()
+	config := fmt.Sprintf(`
+		provider "lambdabased" {
+			backend = "exec"
+			exec_backend {
+				command = ["sh", "-c", "cat >> %s/$LAMBDABASED_FUNCTION_NAME; echo done"]
+			}
+		}
+		resource "lambdabased_resource" "test" {
+			function_name = "create"
+			input = jsonencode({ param = "createupdate-input-param-val" })
+			finalizer {
+				function_name = "destroy"
+				input = jsonencode({ param = "destroy-input-param-val" })
+			}
+		}`, dir)
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest: true,
+		ProviderFactories: map[string]func() (*schema.Provider, error){
+			"lambdabased": func() (*schema.Provider, error) { return Provider(), nil },
+		},
+		CheckDestroy: func(s *terraform.State) error {
+			out, err := ioutil.ReadFile(dir + "/destroy")
+			assert.NoError(t, err)
+			assert.Equal(t, getInputJson("destroy-input-param-val"), string(out))
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: func(s *terraform.State) error {
+					assert.Equal(t, "done\n", getTestResourceState(s).Attributes["result"])
+					out, err := ioutil.ReadFile(dir + "/create")
+					assert.NoError(t, err)
+					assert.Equal(t, getInputJson("createupdate-input-param-val"), string(out))
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestLambdaBasedResource_execBackendVerifyOnPlan(t *testing.T) {
+	dir := t.TempDir()
+	config := fmt.Sprintf(`
+		provider "lambdabased" {
+			backend = "exec"
+			verify_on_plan = true
+			exec_backend {
+				command = ["sh", "-c", "cat >> %s/$LAMBDABASED_FUNCTION_NAME"]
+			}
+		}
+		resource "lambdabased_resource" "test" {
+			function_name = "create"
+			input = jsonencode({ param = "createupdate-input-param-val" })
+		}`, dir)
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest: true,
+		ProviderFactories: map[string]func() (*schema.Provider, error){
+			"lambdabased": func() (*schema.Provider, error) { return Provider(), nil },
+		},
+		Steps: []resource.TestStep{
+			{
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+
+	_, err := os.Stat(dir + "/create")
+	assert.True(t, os.IsNotExist(err), "the command ran during plan")
+}
+
+func createInvokeInput(payload string) *lambda.InvokeInput {
+	return &lambda.InvokeInput{
+		FunctionName: aws.String("func-name"),
+		Qualifier:    aws.String("$LATEST"),
+		Payload:      []byte(payload),
+	}
+}
@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// invocationLimiter bounds the number of concurrent invocations per function.
+// It lives in the provider meta, so it is shared by all resources of the provider.
+type invocationLimiter struct {
+	defaultLimit int
+	perFunction  map[string]int
+
+	mu         sync.Mutex
+	semaphores map[string]chan struct{}
+}
+
+func newInvocationLimiter(defaultLimit int, perFunction map[string]int) *invocationLimiter {
+	return &invocationLimiter{
+		defaultLimit: defaultLimit,
+		perFunction:  perFunction,
+		semaphores:   map[string]chan struct{}{},
+	}
+}
+
+// acquire blocks until the function can be invoked and returns a func releasing
+// the slot. A nil limiter or a limit of zero doesn't restrict anything.
+func (l *invocationLimiter) acquire(ctx context.Context, functionName string) (func(), error) {
+	sem := l.semaphore(functionName)
+	if sem == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case sem <- struct{}{}:
+	default:
+		log.Printf("[INFO] invocation of %s is queued, %d invocation(s) already in progress\n", functionName, cap(sem))
+		select {
+		case sem <- struct{}{}:
+			log.Printf("[INFO] invocation of %s is dequeued\n", functionName)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return func() { <-sem }, nil
+}
+
+func (l *invocationLimiter) semaphore(functionName string) chan struct{} {
+	if l == nil {
+		return nil
+	}
+	limit, ok := l.perFunction[functionName]
+	if !ok {
+		limit = l.defaultLimit
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sem, ok := l.semaphores[functionName]
+	if !ok {
+		sem = make(chan struct{}, limit)
+		l.semaphores[functionName] = sem
+	}
+	return sem
+}
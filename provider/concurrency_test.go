@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvocationLimiter_perFunctionLimit(t *testing.T) {
+	l := newInvocationLimiter(0, map[string]int{"limited-func": 1})
+
+	release, err := l.acquire(context.Background(), "limited-func")
+	assert.NoError(t, err)
+
+	// Functions without a limit are never queued
+	for i := 0; i < 3; i++ {
+		_, err := l.acquire(context.Background(), "unlimited-func")
+		assert.NoError(t, err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		release, _ := l.acquire(context.Background(), "limited-func")
+		close(acquired)
+		release()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second invocation wasn't queued")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second invocation wasn't dequeued")
+	}
+}
+
+func TestInvocationLimiter_defaultLimit(t *testing.T) {
+	l := newInvocationLimiter(2, map[string]int{"unlimited-func": 0})
+
+	for i := 0; i < 2; i++ {
+		_, err := l.acquire(context.Background(), "a-func")
+		assert.NoError(t, err)
+	}
+	_, err := l.acquire(context.Background(), "unlimited-func")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx, "a-func")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestInvocationLimiter_nil(t *testing.T) {
+	var l *invocationLimiter
+	release, err := l.acquire(context.Background(), "a-func")
+	assert.NoError(t, err)
+	release()
+}
+
+// invokeQueued invokes the function described by data while the only slot of
+// its concurrency limit is taken for over a second. It returns the input of the
+// invocation along with the time the slot was released.
+func invokeQueued(t *testing.T, meta *providerMeta, data map[string]interface{}) (*lambda.InvokeInput, time.Time) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	var params *lambda.InvokeInput
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, p *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			params = p
+			return createLambdaInvokeOutput(false), nil
+		})
+	meta.client = m
+	meta.limiter = newInvocationLimiter(1, nil)
+
+	release, err := meta.limiter.acquire(context.Background(), data["function_name"].(string))
+	assert.NoError(t, err)
+	done := make(chan error)
+	go func() {
+		_, err := callLambda("queued-id", data, meta)
+		done <- err
+	}()
+
+	time.Sleep(1100 * time.Millisecond)
+	released := time.Now()
+	release()
+	assert.NoError(t, <-done)
+	return params, released
+}
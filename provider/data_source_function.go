@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func LambdaBasedFunctionDataSource() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceFunctionRead,
+
+		Schema: map[string]*schema.Schema{
+			"function_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"qualifier": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "$LATEST",
+			},
+			"arn": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"code_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"runtime": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_modified": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"routing_additional_version_weights": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeFloat},
+			},
+		},
+	}
+}
+
+func dataSourceFunctionRead(d *schema.ResourceData, meta interface{}) error {
+	ctx := context.TODO()
+	client, err := meta.(*providerMeta).getFunctionClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	function, err := describeFunction(ctx, client, d.Get("function_name").(string), d.Get("qualifier").(string))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(function.arn)
+	d.Set("arn", function.arn)
+	d.Set("version", function.version)
+	d.Set("code_sha256", function.codeSha256)
+	d.Set("runtime", function.runtime)
+	d.Set("last_modified", function.lastModified)
+	d.Set("routing_additional_version_weights", function.routingWeights)
+	return nil
+}
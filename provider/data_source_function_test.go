@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"log"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thetradedesk/terraform-provider-lambdabased/provider/mocks"
+)
+
+func TestLambdaBasedFunctionDataSource(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	m := mocks.NewMockFunctionClient(c)
+
+	m.EXPECT().GetFunction(gomock.Any(), &lambda.GetFunctionInput{
+		FunctionName: aws.String("install-chart"),
+		Qualifier:    aws.String("live"),
+	}).Return(createGetFunctionOutput("install-chart", "7", "code-hash-7"), nil).AnyTimes()
+	m.EXPECT().GetAlias(gomock.Any(), &lambda.GetAliasInput{
+		FunctionName: aws.String("install-chart"),
+		Name:         aws.String("live"),
+	}).Return(&lambda.GetAliasOutput{
+		FunctionVersion: aws.String("7"),
+		RoutingConfig: &lambdatypes.AliasRoutingConfiguration{
+			AdditionalVersionWeights: map[string]float64{"8": 0.1},
+		},
+	}, nil).AnyTimes()
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockFunctionProviderFactories(nil, m),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				data "lambdabased_function" "test" {
+					function_name = "install-chart"
+					qualifier = "live"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.lambdabased_function.test", "arn", "arn:aws:lambda:us-east-1:123456789012:function:install-chart:7"),
+					resource.TestCheckResourceAttr("data.lambdabased_function.test", "version", "7"),
+					resource.TestCheckResourceAttr("data.lambdabased_function.test", "code_sha256", "code-hash-7"),
+					resource.TestCheckResourceAttr("data.lambdabased_function.test", "runtime", "python3.9"),
+					resource.TestCheckResourceAttr("data.lambdabased_function.test", "routing_additional_version_weights.8", "0.1"),
+				),
+			},
+		},
+	})
+}
+
+func TestIsAlias(t *testing.T) {
+	assert.False(t, isAlias(""))
+	assert.False(t, isAlias("$LATEST"))
+	assert.False(t, isAlias("12"))
+	assert.True(t, isAlias("live"))
+}
+
+func createMockFunctionProviderFactories(lambdaClient LambdaClient, functionClient FunctionClient) map[string]func() (*schema.Provider, error) {
+	return map[string]func() (*schema.Provider, error){
+		"lambdabased": func() (*schema.Provider, error) {
+			p := createProvider(func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+				meta, diags := newProviderMeta(d, lambdaClient)
+				if meta != nil {
+					meta.functionClient = functionClient
+				}
+				return meta, diags
+			})
+			raw := map[string]interface{}{"region": "us-east-1"}
+			err := p.Configure(context.Background(), terraform.NewResourceConfigRaw(raw))
+			if err != nil {
+				log.Fatal(err)
+			}
+			return p, nil
+		},
+	}
+}
+
+func createGetFunctionOutput(functionName, version, codeSha256 string) *lambda.GetFunctionOutput {
+	return &lambda.GetFunctionOutput{
+		Configuration: &lambdatypes.FunctionConfiguration{
+			FunctionArn:  aws.String("arn:aws:lambda:us-east-1:123456789012:function:" + functionName + ":" + version),
+			Version:      aws.String(version),
+			CodeSha256:   aws.String(codeSha256),
+			Runtime:      lambdatypes.RuntimePython39,
+			LastModified: aws.String("2022-07-01T00:00:00.000+0000"),
+		},
+	}
+}
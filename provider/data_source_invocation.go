@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func LambdaBasedInvocationDataSource() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceInvocationRead,
+
+		Schema: map[string]*schema.Schema{
+			"function_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"function_name", "function_url", "state_machine_arn"},
+			},
+			"function_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https"}),
+			},
+			"state_machine_arn": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"qualifier": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "$LATEST",
+			},
+			"input": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"sensitive_result": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"outputs": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"result": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"result_sensitive": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"output_values": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"output_values_sensitive": {
+				Type:      schema.TypeMap,
+				Computed:  true,
+				Sensitive: true,
+				Elem:      &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceInvocationRead(d *schema.ResourceData, meta interface{}) error {
+	sensitive := d.Get("sensitive_result").(bool)
+	data := map[string]interface{}{
+		"conceal_result": sensitive,
+		"request_type":   "read",
+	}
+	for _, param := range []string{"function_name", "function_url", "state_machine_arn", "qualifier", "input"} {
+		data[param] = d.Get(param)
+	}
+	// The defaults and the secrets don't change during a provider run, so the
+	// user supplied input identifies the invocation without exposing them in the ID
+	key := invocationCacheKey(data)
+
+	input, err := mergeDefaultInput(data["input"].(string), meta.(*providerMeta))
+	if err != nil {
+		return err
+	}
+	data["input"] = input
+	if err := resolveSecrets(data, meta.(*providerMeta)); err != nil {
+		return err
+	}
+
+	res, err := meta.(*providerMeta).invocations.get(key, func() ([]byte, error) {
+		return callLambda(key, data, meta)
+	})
+	if err != nil {
+		return err
+	}
+
+	paths := d.Get("outputs").(map[string]interface{})
+	// The synthetic result of dry run mode has nothing to extract outputs from
+	if meta.(*providerMeta).dryRun {
+		paths = nil
+	}
+	outputs := map[string]string{}
+	for name, path := range paths {
+		v, err := lookupJSONPath(string(res), path.(string))
+		if err != nil {
+			return fmt.Errorf("outputs.%s: %w", name, err)
+		}
+		outputs[name] = jsonValueString(v)
+	}
+
+	d.SetId(key)
+	if sensitive {
+		d.Set("result", "")
+		d.Set("result_sensitive", string(res))
+		d.Set("output_values", map[string]string{})
+		d.Set("output_values_sensitive", outputs)
+	} else {
+		d.Set("result", string(res))
+		d.Set("result_sensitive", "")
+		d.Set("output_values", outputs)
+		d.Set("output_values_sensitive", map[string]string{})
+	}
+	return nil
+}
+
+// invocationCacheKey identifies an invocation by its target and its input as
+// configured, before the defaults and the secrets are added. Sensitive reads
+// get their own invocation, so that their result is concealed everywhere.
+func invocationCacheKey(data map[string]interface{}) string {
+	h := sha256.New()
+	for _, param := range []string{"function_name", "function_url", "state_machine_arn", "qualifier", "input", "conceal_result"} {
+		fmt.Fprintf(h, "%s=%v\n", param, data[param])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// invocationCache makes sure identical data source invocations are made only
+// once per provider run, i.e. once per plan or apply, even when read concurrently.
+type invocationCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedInvocation
+}
+
+type cachedInvocation struct {
+	once   sync.Once
+	result []byte
+	err    error
+}
+
+func newInvocationCache() *invocationCache {
+	return &invocationCache{entries: map[string]*cachedInvocation{}}
+}
+
+func (c *invocationCache) get(key string, invoke func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cachedInvocation{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.result, entry.err = invoke()
+	})
+	return entry.result, entry.err
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+const invocationDataSourceConfig = `
+	data "lambdabased_invocation" "first" {
+		function_name = "describe-cluster"
+		input = jsonencode({ cluster = "c1" })
+		outputs = {
+			endpoint = "cluster.endpoint"
+			port     = "cluster.ports.0"
+		}
+	}
+
+	data "lambdabased_invocation" "second" {
+		function_name = "describe-cluster"
+		input = jsonencode({ cluster = "c1" })
+		sensitive_result = true
+		outputs = {
+			endpoint = "cluster.endpoint"
+		}
+	}`
+
+func TestLambdaBasedInvocationDataSource(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	// The sensitive data source isn't served the invocation of the other one
+	concealedResults := map[bool]bool{}
+	m.EXPECT().Invoke(gomock.Any(), &lambda.InvokeInput{
+		FunctionName:   aws.String("describe-cluster"),
+		InvocationType: "RequestResponse",
+		Payload:        []byte(`{"cluster":"c1"}`),
+		Qualifier:      aws.String("$LATEST"),
+	}).DoAndReturn(func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+		_, concealResult := concealmentFromContext(ctx)
+		concealedResults[concealResult] = true
+		return &lambda.InvokeOutput{Payload: []byte(`{"cluster":{"endpoint":"https://c1","ports":[443]}}`)}, nil
+	}).AnyTimes()
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps: []resource.TestStep{
+			{
+				Config: invocationDataSourceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.lambdabased_invocation.first", "output_values.endpoint", "https://c1"),
+					resource.TestCheckResourceAttr("data.lambdabased_invocation.first", "output_values.port", "443"),
+					resource.TestCheckResourceAttr("data.lambdabased_invocation.first", "result", `{"cluster":{"endpoint":"https://c1","ports":[443]}}`),
+					resource.TestCheckResourceAttr("data.lambdabased_invocation.second", "result", ""),
+					resource.TestCheckResourceAttr("data.lambdabased_invocation.second", "output_values_sensitive.endpoint", "https://c1"),
+					func(s *terraform.State) error {
+						assert.Equal(t, map[bool]bool{false: true, true: true}, concealedResults)
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestLambdaBasedInvocationDataSource_secrets(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	m.EXPECT().Invoke(gomock.Any(), &lambda.InvokeInput{
+		FunctionName:   aws.String("describe-cluster"),
+		InvocationType: "RequestResponse",
+		Payload:        []byte(`{"cluster":"c1","password":"provider-secret-val","token":"default-secret-val"}`),
+		Qualifier:      aws.String("$LATEST"),
+	}).Return(&lambda.InvokeOutput{Payload: []byte(`{}`)}, nil).AnyTimes()
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					provider "lambdabased" {
+						default_secret_input = jsonencode({ token = "default-secret-val" })
+						secrets = { password = "provider-secret-val" }
+					}
+					data "lambdabased_invocation" "test" {
+						function_name = "describe-cluster"
+						input = jsonencode({ cluster = "c1", password = "$${provider:password}" })
+					}`,
+				// The ID is derived from the input as configured
+				Check: resource.TestCheckResourceAttr("data.lambdabased_invocation.test", "id", invocationCacheKey(map[string]interface{}{
+					"function_name":     "describe-cluster",
+					"function_url":      "",
+					"state_machine_arn": "",
+					"qualifier":         "$LATEST",
+					"input":             `{"cluster":"c1","password":"${provider:password}"}`,
+					"conceal_result":    false,
+				})),
+			},
+		},
+	})
+}
+
+func TestInvocationCache(t *testing.T) {
+	cache := newInvocationCache()
+	var invocations int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := cache.get("key", func() ([]byte, error) {
+				atomic.AddInt32(&invocations, 1)
+				return []byte("result-val"), nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "result-val", string(res))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), invocations)
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	document := `{"cluster":{"name":"c1","ports":[443,8443],"tags":{"a":"b"}}}`
+
+	v, err := lookupJSONPath(document, "cluster.ports.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "8443", jsonValueString(v))
+
+	v, err = lookupJSONPath(document, "cluster.tags")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":"b"}`, jsonValueString(v))
+
+	_, err = lookupJSONPath(document, "cluster.missing")
+	assert.Error(t, err)
+	_, err = lookupJSONPath(document, "cluster.ports.2")
+	assert.Error(t, err)
+	_, err = lookupJSONPath("not-json", "cluster")
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLambdaBasedResource_dryRun(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	var steps []resource.TestStep
+
+	configParam := newConfigParameters()
+	dryRunConfig := `
+		provider "lambdabased" {
+			dry_run = true
+			dry_run_result = "synthetic-result-val"
+		}` + generateTestConfig(configParam)
+
+	// Nothing is invoked in dry run mode
+	steps = append(steps, resource.TestStep{
+		Config: dryRunConfig,
+		Check: func(s *terraform.State) error {
+			rs := getTestResourceState(s)
+			assert.Equal(t, "synthetic-result-val", rs.Attributes["result"])
+			assert.Equal(t, "true", rs.Attributes["dry_run_pending"])
+			return nil
+		},
+	})
+
+	// Destroying keeps the resource in state
+	steps = append(steps, resource.TestStep{
+		Config:      dryRunConfig,
+		Destroy:     true,
+		ExpectError: regexp.MustCompile("is not destroyed"),
+	})
+
+	// Turning dry run off invokes the function for real
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, false)).Return(createLambdaInvokeOutput(false), nil)
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(configParam),
+		Check: func(s *terraform.State) error {
+			rs := getTestResourceState(s)
+			assert.Equal(t, "result-val", rs.Attributes["result"])
+			assert.Equal(t, "false", rs.Attributes["dry_run_pending"])
+			return nil
+		},
+	})
+
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, true)).Return(createLambdaInvokeOutput(false), nil)
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps:             steps,
+	})
+}
+
+func TestLambdaBasedInvocationDataSource_dryRun(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					provider "lambdabased" {
+						dry_run = true
+					}` + invocationDataSourceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.lambdabased_invocation.first", "result", ""),
+					resource.TestCheckResourceAttr("data.lambdabased_invocation.first", "output_values.%", "0"),
+					resource.TestCheckResourceAttr("data.lambdabased_invocation.second", "output_values_sensitive.%", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestLambdaBasedResource_dryRunCreate(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	var steps []resource.TestStep
+
+	configParam := newConfigParameters()
+	configParam.FinalizerBlockOn = false
+	configParam.ExtraAttributes = `
+		invoke_on = ["create"]
+		pre_update {
+			function_name = "pre-update"
+			input = "{}"
+		}`
+	dryRunConfig := `
+		provider "lambdabased" {
+			dry_run = true
+		}` + generateTestConfig(configParam)
+
+	steps = append(steps, resource.TestStep{
+		Config: dryRunConfig,
+	})
+
+	// Resources created in dry run mode are created for real, without the update hooks
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, false)).Return(createLambdaInvokeOutput(false), nil)
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(configParam),
+		Check: func(s *terraform.State) error {
+			rs := getTestResourceState(s)
+			assert.Equal(t, "result-val", rs.Attributes["result"])
+			assert.Equal(t, "create", rs.Attributes["planned_invocation"])
+			assert.Equal(t, "false", rs.Attributes["dry_run_pending"])
+			return nil
+		},
+	})
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps:             steps,
+	})
+}
+
+func TestRedactSecretInput(t *testing.T) {
+	secret, _ := parseJSONObject(`{"token":"t","cluster":{"ca":"c"}}`)
+	assert.Equal(t,
+		`{"cluster":{"ca":"<redacted>","name":"n"},"param":"p","token":"<redacted>"}`,
+		redactSecretInput(`{"param":"p","token":"t","cluster":{"name":"n","ca":"c"}}`, secret))
+	assert.Equal(t, `{"param":"p"}`, redactSecretInput(`{"param":"p"}`, nil))
+}
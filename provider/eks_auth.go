@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	eksTokenPrefix     = "k8s-aws-v1."
+	eksClusterIDHeader = "x-k8s-aws-id"
+)
+
+func eksAuthSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"cluster_name": {
+					Type:     schema.TypeString,
+					Required: true,
+				},
+				"inject_path": {
+					Type:     schema.TypeString,
+					Optional: true,
+					Default:  "token",
+				},
+				"assume_role": {
+					Type:     schema.TypeList,
+					Optional: true,
+					MaxItems: 1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"role_arn": {
+								Type:     schema.TypeString,
+								Required: true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// injectsEKSToken tells whether the invocation described by data has an
+// eks_auth block.
+func injectsEKSToken(data map[string]interface{}) bool {
+	eksAuth, _ := data["eks_auth"].([]interface{})
+	return len(eksAuth) > 0
+}
+
+// injectEKSToken mints a fresh EKS authentication token if the invocation
+// described by data has an eks_auth block, and returns the input with the token
+// injected.
+func injectEKSToken(ctx context.Context, data map[string]interface{}, meta *providerMeta) (string, error) {
+	input := data["input"].(string)
+	if !injectsEKSToken(data) {
+		return input, nil
+	}
+	eksAuth := data["eks_auth"].([]interface{})[0].(map[string]interface{})
+
+	cfg, err := meta.getAWSConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("eks_auth: %w", err)
+	}
+	if assumeRole := eksAuth["assume_role"].([]interface{}); len(assumeRole) > 0 {
+		cfg = cfg.Copy()
+		role := assumeRole[0].(map[string]interface{})["role_arn"].(string)
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), role))
+	}
+
+	token, err := mintEKSToken(ctx, cfg, eksAuth["cluster_name"].(string))
+	if err != nil {
+		return "", fmt.Errorf("eks_auth: %w", err)
+	}
+
+	obj, err := parseJSONObject(input)
+	if err != nil {
+		return "", fmt.Errorf("eks_auth: input %w", err)
+	}
+	if obj == nil {
+		obj = map[string]interface{}{}
+	}
+	if err := setJSONPath(obj, eksAuth["inject_path"].(string), token); err != nil {
+		return "", fmt.Errorf("eks_auth: %w", err)
+	}
+	if input, err = marshalJSON(obj); err != nil {
+		return "", err
+	}
+	return input, nil
+}
+
+// mintEKSToken returns a token authenticating to the EKS cluster, as in
+// `aws eks get-token`: a presigned STS GetCallerIdentity request bound to the
+// cluster, which is valid for 15 minutes.
+func mintEKSToken(ctx context.Context, cfg aws.Config, clusterName string) (string, error) {
+	presignClient := sts.NewPresignClient(sts.NewFromConfig(cfg))
+	req, err := presignClient.PresignGetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}, func(o *sts.PresignOptions) {
+		o.ClientOptions = append(o.ClientOptions, func(o *sts.Options) {
+			o.APIOptions = append(o.APIOptions,
+				smithyhttp.AddHeaderValue(eksClusterIDHeader, clusterName),
+				smithyhttp.AddHeaderValue("X-Amz-Expires", "60"))
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("presigning GetCallerIdentity: %w", err)
+	}
+	return eksTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(req.URL)), nil
+}
+
+// setJSONPath sets the value at a dot separated path in a JSON object, creating
+// the intermediate objects.
+func setJSONPath(obj map[string]interface{}, path string, value interface{}) error {
+	segments := strings.Split(path, ".")
+	for _, segment := range segments[:len(segments)-1] {
+		child, ok := obj[segment]
+		if !ok {
+			child = map[string]interface{}{}
+			obj[segment] = child
+		}
+		childObj, ok := child.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%q in path %q is not an object", segment, path)
+		}
+		obj = childObj
+	}
+	obj[segments[len(segments)-1]] = value
+	return nil
+}
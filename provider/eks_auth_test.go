@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLambdaBasedResource_eksAuth(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	tokens := map[string]string{}
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			var payload struct {
+				Chart string
+				Auth  struct{ Token string }
+			}
+			assert.NoError(t, json.Unmarshal(params.Payload, &payload))
+			assert.Equal(t, "my-chart", payload.Chart)
+			tokens[aws.ToString(params.FunctionName)] = payload.Auth.Token
+			concealInput, _ := concealmentFromContext(ctx)
+			assert.True(t, concealInput)
+			return createLambdaInvokeOutput(false), nil
+		}).Times(2)
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest: true,
+		ProviderFactories: map[string]func() (*schema.Provider, error){
+			"lambdabased": func() (*schema.Provider, error) {
+				p := createProvider(func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+					meta, diags := newProviderMeta(d, m)
+					if meta != nil {
+						meta.loadAWSConfig = func(ctx context.Context) (aws.Config, error) { return createTestAWSConfig(), nil }
+					}
+					return meta, diags
+				})
+				if err := p.Configure(context.Background(), terraform.NewResourceConfigRaw(nil)); err != nil {
+					log.Fatal(err)
+				}
+				return p, nil
+			},
+		},
+		CheckDestroy: func(s *terraform.State) error {
+			assert.True(t, strings.HasPrefix(tokens["uninstall-chart"], eksTokenPrefix))
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				resource "lambdabased_resource" "test" {
+					function_name = "install-chart"
+					input = jsonencode({ chart = "my-chart" })
+					eks_auth {
+						cluster_name = "my-cluster"
+						inject_path = "auth.token"
+					}
+					finalizer {
+						function_name = "uninstall-chart"
+						input = jsonencode({ chart = "my-chart" })
+						eks_auth {
+							cluster_name = "my-cluster"
+							inject_path = "auth.token"
+						}
+					}
+				}`,
+				Check: func(s *terraform.State) error {
+					assert.True(t, strings.HasPrefix(tokens["install-chart"], eksTokenPrefix))
+					for _, v := range getTestResourceState(s).Attributes {
+						assert.NotContains(t, v, eksTokenPrefix)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestCallLambda_eksAuthQueued(t *testing.T) {
+	meta := &providerMeta{
+		loadAWSConfig: func(ctx context.Context) (aws.Config, error) { return createTestAWSConfig(), nil },
+	}
+	params, released := invokeQueued(t, meta, map[string]interface{}{
+		"function_name": "queued-func",
+		"qualifier":     "$LATEST",
+		"input":         `{"chart":"my-chart"}`,
+		"eks_auth": []interface{}{map[string]interface{}{
+			"cluster_name": "my-cluster",
+			"inject_path":  "token",
+			"assume_role":  []interface{}{},
+		}},
+	})
+
+	// The token is minted once the invocation is let through
+	var payload struct{ Token string }
+	assert.NoError(t, json.Unmarshal(params.Payload, &payload))
+	presigned, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(payload.Token, eksTokenPrefix))
+	assert.NoError(t, err)
+	u, err := url.Parse(string(presigned))
+	assert.NoError(t, err)
+	minted, err := time.Parse("20060102T150405Z", u.Query().Get("X-Amz-Date"))
+	assert.NoError(t, err)
+	assert.False(t, minted.Before(released.Truncate(time.Second)))
+}
+
+func TestMintEKSToken(t *testing.T) {
+	token, err := mintEKSToken(context.Background(), createTestAWSConfig(), "my-cluster")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, eksTokenPrefix))
+
+	presigned, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, eksTokenPrefix))
+	assert.NoError(t, err)
+	u, err := url.Parse(string(presigned))
+	assert.NoError(t, err)
+	assert.Equal(t, "sts.us-east-1.amazonaws.com", u.Host)
+	assert.Equal(t, "GetCallerIdentity", u.Query().Get("Action"))
+	assert.Equal(t, "60", u.Query().Get("X-Amz-Expires"))
+	assert.Contains(t, u.Query().Get("X-Amz-SignedHeaders"), eksClusterIDHeader)
+}
+
+func TestSetJSONPath(t *testing.T) {
+	obj := map[string]interface{}{"auth": map[string]interface{}{"user": "u"}, "param": "p"}
+	assert.NoError(t, setJSONPath(obj, "auth.token", "t"))
+	assert.Equal(t, map[string]interface{}{"auth": map[string]interface{}{"user": "u", "token": "t"}, "param": "p"}, obj)
+	assert.Error(t, setJSONPath(obj, "param.token", "t"))
+}
@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	sfntypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	finalizerOnDestroyRun  = "run"
+	finalizerOnDestroySkip = "skip"
+)
+
+const (
+	onFailureFail     = "fail"
+	onFailureContinue = "continue"
+	onFailureRetry    = "retry"
+)
+
+// invocationBlocks are the blocks of lambdabased_resource describing additional
+// functions to be invoked, in the order they are verified.
+var invocationBlocks = []string{"pre_update", "post_update", "rollback", "finalizer"}
+
+// retryDelay is the delay before the first retry of a block, it grows linearly
+// with every attempt.
+var retryDelay = 5 * time.Second
+
+// runFinalizers invokes the finalizers in order, applying their failure policies.
+func runFinalizers(d *schema.ResourceData, meta interface{}) error {
+	return runBlocks(d.Id(), "finalizer", d.Get("finalizer").([]interface{}), func(finalizer map[string]interface{}) (map[string]interface{}, error) {
+		return finalizerInvocation(d, finalizer, meta)
+	}, meta)
+}
+
+// runBlocks invokes the given blocks in order, applying their failure policies.
+// The invocation of every block is described by prepare.
+func runBlocks(id, name string, blocks []interface{}, prepare func(map[string]interface{}) (map[string]interface{}, error), meta interface{}) error {
+	for i, block := range blocks {
+		data, err := prepare(block.(map[string]interface{}))
+		if err != nil {
+			return fmt.Errorf("%s.%d: %w", name, i, err)
+		}
+
+		err = invokeBlock(id, data, meta)
+		if err == nil {
+			continue
+		}
+		if data["on_failure"] != onFailureContinue {
+			return fmt.Errorf("%s.%d: %w", name, i, err)
+		}
+		log.Printf("[WARN] %s %s.%d failed, continuing with the next one: %s\n", id, name, i, err)
+	}
+	return nil
+}
+
+// finalizerInvocation resolves the ${result.<path>} placeholders in the input of
+// the finalizer and wraps it with the context of the resource if requested.
+func finalizerInvocation(d *schema.ResourceData, finalizer map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	data, err := extractBlockInformation(finalizer, meta)
+	if err != nil {
+		return nil, err
+	}
+	concealInput := d.Get("conceal_input").(bool)
+	concealResult := d.Get("conceal_result").(bool)
+	data["conceal_result"] = concealResult
+	data["request_type"] = "delete"
+
+	input, err := resolvePlaceholders(data["input"].(string), resultResolver(d.Get("result").(string), concealResult))
+	if err != nil {
+		return nil, err
+	}
+
+	if includeContext, _ := data["include_context"].(bool); includeContext {
+		wrapped := map[string]interface{}{
+			"resource_id": d.Id(),
+			"input":       parseJSONValue(input),
+		}
+		if !concealResult {
+			wrapped["result"] = parseJSONValue(d.Get("result").(string))
+		}
+		if !concealInput {
+			createInput, err := mergeDefaultInput(d.Get("input").(string), meta.(*providerMeta))
+			if err != nil {
+				return nil, err
+			}
+			wrapped["create_input"] = parseJSONValue(createInput)
+		}
+		encryptEnvelopeInputs(data, d.Get("input_encryption").([]interface{}), wrapped)
+		if input, err = marshalJSON(wrapped); err != nil {
+			return nil, err
+		}
+	}
+	data["input"] = input
+	return data, nil
+}
+
+func invokeBlock(id string, data map[string]interface{}, meta interface{}) error {
+	attempts := 1
+	if data["on_failure"] == onFailureRetry {
+		attempts = data["retry_attempts"].(int)
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			log.Printf("[INFO] %s retrying %s (attempt %d of %d) after: %s\n", id, data["request_type"], attempt, attempts, err)
+			time.Sleep(time.Duration(attempt-1) * retryDelay)
+		}
+
+		var res []byte
+		res, err = callLambda(id, data, meta)
+		if err == nil {
+			if concealResult, _ := data["conceal_result"].(bool); !concealResult {
+				log.Printf("%s received %s response: %s\n", id, data["request_type"], string(res))
+			}
+			return nil
+		}
+		if ignore, _ := data["ignore_not_found"].(bool); ignore && isNotFound(err) {
+			log.Printf("[WARN] %s %s function doesn't exist, treating it as succeeded: %s\n", id, data["request_type"], err)
+			return nil
+		}
+	}
+	return err
+}
+
+// isNotFound tells whether the invoked function or state machine doesn't exist.
+func isNotFound(err error) bool {
+	var functionNotFound *lambdatypes.ResourceNotFoundException
+	var stateMachineNotFound *sfntypes.StateMachineDoesNotExist
+	return errors.As(err, &functionNotFound) || errors.As(err, &stateMachineNotFound)
+}
+
+// validateBlockTargets makes sure every block sets exactly one of function_name,
+// function_url and state_machine_arn. Unknown values count as set.
+func validateBlockTargets(name string, blocks cty.Value) error {
+	if blocks.IsNull() || !blocks.IsKnown() {
+		return nil
+	}
+	for i, it := 0, blocks.ElementIterator(); it.Next(); i++ {
+		_, block := it.Element()
+		if block.IsNull() || !block.IsKnown() {
+			continue
+		}
+		set := 0
+		for _, param := range []string{"function_name", "function_url", "state_machine_arn"} {
+			if !block.GetAttr(param).IsNull() {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("%s.%d: exactly one of function_name, function_url or state_machine_arn must be set", name, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+const multipleFinalizersConfig = `
+	resource "lambdabased_resource" "test" {
+		function_name = "install-chart"
+		input = jsonencode({ chart = "my-chart" })
+		finalizer {
+			function_name = "uninstall-chart"
+			input = jsonencode({ chart = "my-chart" })
+			on_failure = "continue"
+		}
+		finalizer {
+			function_name = "delete-namespace"
+			input = jsonencode({ namespace = "my-namespace" })
+			ignore_not_found = true
+		}
+		finalizer {
+			function_name = "deregister-dns"
+			input = jsonencode({ name = "my-chart.example.com" })
+			on_failure = "retry"
+			retry_attempts = 2
+		}
+	}`
+
+func TestLambdaBasedResource_multipleFinalizers(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	retryDelay = 0
+
+	var invoked []string
+	invoke := func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+		name := aws.ToString(params.FunctionName)
+		invoked = append(invoked, name)
+		switch {
+		case name == "uninstall-chart":
+			return createLambdaInvokeOutput(true), nil
+		case name == "delete-namespace":
+			return nil, &lambdatypes.ResourceNotFoundException{Message: aws.String("this-error-is-expected")}
+		case name == "deregister-dns" && len(invoked) == 4:
+			return nil, fmt.Errorf("this-error-is-expected")
+		}
+		return createLambdaInvokeOutput(false), nil
+	}
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(invoke).AnyTimes()
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		CheckDestroy: func(s *terraform.State) error {
+			// Finalizers run in order, the failing one is skipped, the missing one
+			// is treated as finalized and the flaky one succeeds on retry
+			assert.Equal(t, []string{"install-chart", "uninstall-chart", "delete-namespace", "deregister-dns", "deregister-dns"}, invoked)
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				resource "lambdabased_resource" "test" {
+					function_name = "install-chart"
+					input = jsonencode({ chart = "my-chart" })
+					finalizer {
+						input = jsonencode({ chart = "my-chart" })
+					}
+				}`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`finalizer.0: exactly one of function_name, function_url or\s+state_machine_arn must be set`),
+			},
+			{
+				Config: multipleFinalizersConfig,
+			},
+		},
+	})
+}
+
+func TestIsNotFound(t *testing.T) {
+	assert.True(t, isNotFound(fmt.Errorf("Lambda Invocation (id) failed: %w", &lambdatypes.ResourceNotFoundException{})))
+	assert.False(t, isNotFound(fmt.Errorf("Lambda Invocation (id) failed: %w", &lambdatypes.TooManyRequestsException{})))
+}
+
+func TestLambdaBasedResource_finalizerContext(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	var resourceID, finalizerPayload string
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			if aws.ToString(params.FunctionName) == "uninstall-chart" {
+				finalizerPayload = string(params.Payload)
+				return createLambdaInvokeOutput(false), nil
+			}
+			return &lambda.InvokeOutput{Payload: []byte(`{"release":"my-release","ports":[443]}`)}, nil
+		}).Times(2)
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		CheckDestroy: func(s *terraform.State) error {
+			assert.JSONEq(t, `{
+				"resource_id": "`+resourceID+`",
+				"input": { "release": "my-release", "port": 443, "url": "https://my-release:443" },
+				"result": { "release": "my-release", "ports": [443] },
+				"create_input": { "chart": "my-chart" }
+			}`, finalizerPayload)
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				resource "lambdabased_resource" "test" {
+					function_name = "install-chart"
+					input = jsonencode({ chart = "my-chart" })
+					finalizer {
+						function_name = "uninstall-chart"
+						input = jsonencode({
+							release = "$${result.release}"
+							port    = "$${result.ports.0}"
+							url     = "https://$${result.release}:$${result.ports.0}"
+						})
+						include_context = true
+					}
+				}`,
+				Check: func(s *terraform.State) error {
+					resourceID = getTestResourceState(s).ID
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestResolvePlaceholders(t *testing.T) {
+	resolver := resultResolver(`{"release":"my-release"}`, false)
+	input, err := resolvePlaceholders(`{"a":"${result.release}","b":["${result}"],"c":"${other.x}"}`, resolver)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a":"my-release","b":[{"release":"my-release"}],"c":"${other.x}"}`, input)
+
+	_, err = resolvePlaceholders(`{"a":"${result.missing}"}`, resolver)
+	assert.Error(t, err)
+	_, err = resolvePlaceholders(`{"a":"${result.release}"}`, resultResolver("", true))
+	assert.Error(t, err)
+}
+
+func TestLambdaBasedResource_destroyControls(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	var steps []resource.TestStep
+
+	configParam := newConfigParameters()
+	config := func(extraAttributes string) string {
+		cp := configParam
+		cp.ExtraAttributes = extraAttributes
+		return generateTestConfig(cp)
+	}
+
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, false)).Return(createLambdaInvokeOutput(false), nil)
+	steps = append(steps, resource.TestStep{
+		Config: config("deletion_protection = true"),
+	})
+
+	// Protected resources can't be destroyed
+	steps = append(steps, resource.TestStep{
+		Config:      config("deletion_protection = true"),
+		Destroy:     true,
+		ExpectError: regexp.MustCompile("deletion_protection is enabled"),
+	})
+
+	// Turning the protection off and skipping the finalizer doesn't invoke anything
+	steps = append(steps, resource.TestStep{
+		Config: config(`finalizer_on_destroy = "skip"`),
+	})
+	steps = append(steps, resource.TestStep{
+		Config:  config(`finalizer_on_destroy = "skip"`),
+		Destroy: true,
+	})
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps:             steps,
+	})
+}
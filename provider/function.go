@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+const (
+	functionChangeCode    = "code"
+	functionChangeVersion = "version"
+	functionChangeConfig  = "config"
+)
+
+// FunctionClient is the subset of the Lambda API used to look up the functions
+// and aliases that are invoked. *lambda.Client implements it.
+type FunctionClient interface {
+	GetFunction(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error)
+	GetAlias(ctx context.Context, params *lambda.GetAliasInput, optFns ...func(*lambda.Options)) (*lambda.GetAliasOutput, error)
+}
+
+// functionDetails is the deployed state of a function version or alias.
+type functionDetails struct {
+	arn            string
+	version        string
+	codeSha256     string
+	runtime        string
+	lastModified   string
+	configHash     string
+	routingWeights map[string]float64
+}
+
+// isAlias tells whether the qualifier names an alias rather than a version.
+func isAlias(qualifier string) bool {
+	if qualifier == "" || qualifier == "$LATEST" {
+		return false
+	}
+	_, err := strconv.ParseUint(qualifier, 10, 64)
+	return err != nil
+}
+
+// describeFunction resolves the version a qualifier points to along with the
+// alias routing configuration, if the qualifier is an alias.
+func describeFunction(ctx context.Context, client FunctionClient, functionName, qualifier string) (*functionDetails, error) {
+	input := &lambda.GetFunctionInput{FunctionName: aws.String(functionName)}
+	if qualifier != "" {
+		input.Qualifier = aws.String(qualifier)
+	}
+	function, err := client.GetFunction(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("getting function %s (qualifier: %s): %w", functionName, qualifier, err)
+	}
+
+	config := function.Configuration
+	ret := &functionDetails{
+		arn:            aws.ToString(config.FunctionArn),
+		version:        aws.ToString(config.Version),
+		codeSha256:     aws.ToString(config.CodeSha256),
+		runtime:        string(config.Runtime),
+		lastModified:   aws.ToString(config.LastModified),
+		configHash:     functionConfigHash(config),
+		routingWeights: map[string]float64{},
+	}
+
+	if isAlias(qualifier) {
+		alias, err := client.GetAlias(ctx, &lambda.GetAliasInput{
+			FunctionName: aws.String(functionName),
+			Name:         aws.String(qualifier),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting alias %s of function %s: %w", qualifier, functionName, err)
+		}
+		if alias.RoutingConfig != nil {
+			for version, weight := range alias.RoutingConfig.AdditionalVersionWeights {
+				ret.routingWeights[version] = weight
+			}
+		}
+	}
+	return ret, nil
+}
+
+// functionConfigHash hashes the code and the settings affecting the behaviour of
+// a function version. Metadata such as the revision or the update status is left out.
+func functionConfigHash(config *lambdatypes.FunctionConfiguration) string {
+	settings := map[string]interface{}{
+		"code_sha256":   aws.ToString(config.CodeSha256),
+		"runtime":       config.Runtime,
+		"handler":       aws.ToString(config.Handler),
+		"memory_size":   aws.ToInt32(config.MemorySize),
+		"timeout":       aws.ToInt32(config.Timeout),
+		"role":          aws.ToString(config.Role),
+		"architectures": config.Architectures,
+	}
+	if config.Environment != nil {
+		settings["environment"] = config.Environment.Variables
+	}
+	var layers []string
+	for _, layer := range config.Layers {
+		layers = append(layers, aws.ToString(layer.Arn))
+	}
+	settings["layers"] = layers
+	if config.EphemeralStorage != nil {
+		settings["ephemeral_storage"] = aws.ToInt32(config.EphemeralStorage.Size)
+	}
+	if config.VpcConfig != nil {
+		settings["subnet_ids"] = config.VpcConfig.SubnetIds
+		settings["security_group_ids"] = config.VpcConfig.SecurityGroupIds
+	}
+
+	// Map keys are sorted when marshalled, so the hash is stable
+	b, _ := json.Marshal(settings)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// functionFingerprint returns the value tracked by trigger_on_function_change.
+func functionFingerprint(function *functionDetails, mode string) string {
+	switch mode {
+	case functionChangeCode:
+		return function.codeSha256
+	case functionChangeVersion:
+		return function.version
+	case functionChangeConfig:
+		return function.configHash
+	}
+	return ""
+}
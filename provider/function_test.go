@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thetradedesk/terraform-provider-lambdabased/provider/mocks"
+)
+
+func TestLambdaBasedResource_triggerOnFunctionChange(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	fm := mocks.NewMockFunctionClient(c)
+	var steps []resource.TestStep
+
+	configParam := newConfigParameters()
+	configParam.ExtraAttributes = `trigger_on_function_change = "code"`
+
+	codeSha256 := "code-hash-1"
+	fm.EXPECT().GetFunction(gomock.Any(), &lambda.GetFunctionInput{
+		FunctionName: aws.String(configParam.FunctionName),
+		Qualifier:    aws.String(configParam.Qualifier),
+	}).DoAndReturn(func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
+		return createGetFunctionOutput(configParam.FunctionName, "$LATEST", codeSha256), nil
+	}).AnyTimes()
+
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, false)).Return(createLambdaInvokeOutput(false), nil)
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(configParam),
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, "code-hash-1", getTestResourceState(s).Attributes["function_fingerprint"])
+			return nil
+		},
+	})
+
+	// Unchanged function doesn't result in an update
+	steps = append(steps, resource.TestStep{
+		Config:             generateTestConfig(configParam),
+		PlanOnly:           true,
+		ExpectNonEmptyPlan: false,
+	})
+
+	// Deploying new code invokes the function again
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, false)).Return(createLambdaInvokeOutput(false), nil)
+	steps = append(steps, resource.TestStep{
+		PreConfig: func() { codeSha256 = "code-hash-2" },
+		Config:    generateTestConfig(configParam),
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, "code-hash-2", getTestResourceState(s).Attributes["function_fingerprint"])
+			return nil
+		},
+	})
+
+	// Switching the mode only stores the new fingerprint
+	configParam.ExtraAttributes = `trigger_on_function_change = "version"`
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(configParam),
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, "$LATEST", getTestResourceState(s).Attributes["function_fingerprint"])
+			return nil
+		},
+	})
+
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, true)).Return(createLambdaInvokeOutput(false), nil)
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockFunctionProviderFactories(m, fm),
+		Steps:             steps,
+	})
+}
+
+func TestLambdaBasedResource_triggerOnFunctionChangeNewFunction(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	fm := mocks.NewMockFunctionClient(c)
+
+	configParam := newConfigParameters()
+	configParam.ExtraAttributes = `trigger_on_function_change = "code"`
+
+	// The function doesn't exist until it is deployed in the same apply
+	deployed := false
+	fm.EXPECT().GetFunction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
+			if !deployed {
+				return nil, &lambdatypes.ResourceNotFoundException{Message: aws.String("Function not found")}
+			}
+			return createGetFunctionOutput(configParam.FunctionName, "$LATEST", "code-hash-1"), nil
+		}).AnyTimes()
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, false)).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			deployed = true
+			return createLambdaInvokeOutput(false), nil
+		})
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, true)).Return(createLambdaInvokeOutput(false), nil)
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockFunctionProviderFactories(m, fm),
+		Steps: []resource.TestStep{
+			{
+				Config: generateTestConfig(configParam),
+				Check: func(s *terraform.State) error {
+					assert.Equal(t, "code-hash-1", getTestResourceState(s).Attributes["function_fingerprint"])
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestFunctionFingerprint(t *testing.T) {
+	config := createGetFunctionOutput("install-chart", "3", "code-hash").Configuration
+	function := &functionDetails{version: "3", codeSha256: "code-hash", configHash: functionConfigHash(config)}
+	assert.Equal(t, "code-hash", functionFingerprint(function, functionChangeCode))
+	assert.Equal(t, "3", functionFingerprint(function, functionChangeVersion))
+
+	config.MemorySize = aws.Int32(512)
+	assert.NotEqual(t, function.configHash, functionConfigHash(config))
+
+	config.MemorySize = nil
+	config.RevisionId = aws.String("new-revision")
+	config.State = lambdatypes.StateActive
+	assert.Equal(t, function.configHash, functionConfigHash(config))
+}
@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunctionURLBackend_invoke(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-1/lambda/aws4_request")
+		assert.Equal(t, "session-token", r.Header.Get("X-Amz-Security-Token"))
+		switch r.URL.Path {
+		case "/function-error":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/stream":
+			flusher := w.(http.Flusher)
+			for _, chunk := range []string{"result", "-", "val"} {
+				w.Write([]byte(chunk))
+				flusher.Flush()
+			}
+			return
+		}
+		w.Write([]byte("result-val"))
+	}))
+	defer server.Close()
+
+	b := newFunctionURLBackend(createTestAWSConfig())
+
+	res, err := b.Invoke(context.Background(), createURLInvokeInput(server.URL+"/"))
+	assert.NoError(t, err)
+	assert.Nil(t, res.FunctionError)
+	assert.Equal(t, "result-val", string(res.Payload))
+
+	res, err = b.Invoke(context.Background(), createURLInvokeInput(server.URL+"/stream"))
+	assert.NoError(t, err)
+	assert.Equal(t, "result-val", string(res.Payload))
+
+	res, err = b.Invoke(context.Background(), createURLInvokeInput(server.URL+"/function-error"))
+	assert.NoError(t, err)
+	assert.Equal(t, "502 Bad Gateway", aws.ToString(res.FunctionError))
+
+	_, err = b.Invoke(context.Background(), createURLInvokeInput(server.URL+"/forbidden"))
+	assert.Error(t, err)
+}
+
+func TestFunctionURLRegion(t *testing.T) {
+	u, _ := url.Parse("https://abcdefg.lambda-url.eu-west-1.on.aws/")
+	assert.Equal(t, "eu-west-1", functionURLRegion(u, "us-east-1"))
+	u, _ = url.Parse("http://127.0.0.1:8080/")
+	assert.Equal(t, "us-east-1", functionURLRegion(u, "us-east-1"))
+}
+
+func TestLambdaBasedResource_functionURL(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = append(received, r.URL.Path+" "+string(body))
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("result-val"))
+	}))
+	defer server.Close()
+
+	config := fmt.Sprintf(`
+		resource "lambdabased_resource" "test" {
+			function_url = "%[1]s/create"
+			input = jsonencode({ param = "createupdate-input-param-val" })
+			finalizer {
+				function_url = "%[1]s/destroy"
+				input = jsonencode({ param = "destroy-input-param-val" })
+			}
+		}`, server.URL)
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest: true,
+		ProviderFactories: map[string]func() (*schema.Provider, error){
+			"lambdabased": func() (*schema.Provider, error) {
+				return createProvider(func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+					meta, diags := newProviderMeta(d, nil)
+					if meta != nil {
+						meta.loadAWSConfig = func(ctx context.Context) (aws.Config, error) { return createTestAWSConfig(), nil }
+					}
+					return meta, diags
+				}), nil
+			},
+		},
+		CheckDestroy: func(s *terraform.State) error {
+			assert.Equal(t, []string{
+				"/create " + getInputJson("createupdate-input-param-val"),
+				"/destroy " + getInputJson("destroy-input-param-val"),
+			}, received)
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config:      `resource "lambdabased_resource" "test" { input = "{}" }`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Invalid combination of arguments`),
+			},
+			{
+				Config: config,
+				Check: func(s *terraform.State) error {
+					assert.Equal(t, "result-val", getTestResourceState(s).Attributes["result"])
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func createTestAWSConfig() aws.Config {
+	return aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", "session-token"),
+	}
+}
+
+func createURLInvokeInput(functionURL string) *lambda.InvokeInput {
+	ret := createInvokeInput(`{}`)
+	ret.FunctionName = aws.String(functionURL)
+	return ret
+}
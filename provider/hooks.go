@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// runUpdateHooks invokes the pre_update or post_update blocks in order. Every
+// block receives its input along with the old and the new input of the resource.
+func runUpdateHooks(d *schema.ResourceData, name string, data map[string]interface{}, meta interface{}) error {
+	return runBlocks(d.Id(), name, d.Get(name).([]interface{}), func(block map[string]interface{}) (map[string]interface{}, error) {
+		envelope := map[string]interface{}{
+			"new_input": parseJSONValue(data["input"].(string)),
+		}
+		if err := addOldInput(d, envelope, meta); err != nil {
+			return nil, err
+		}
+		return blockInvocation(d, name, block, envelope, data, meta)
+	}, meta)
+}
+
+// rollback invokes the rollback block, if any, after the invocation described by
+// data or its post_update failed. It returns the error to be reported.
+func rollback(d *schema.ResourceData, stage string, data map[string]interface{}, failure error, meta interface{}) error {
+	err := runBlocks(d.Id(), "rollback", d.Get("rollback").([]interface{}), func(block map[string]interface{}) (map[string]interface{}, error) {
+		envelope := map[string]interface{}{
+			"request_type": data["request_type"],
+			"stage":        stage,
+			"failed_input": parseJSONValue(data["input"].(string)),
+			"error":        failure.Error(),
+		}
+		if data["request_type"] == requestTypeUpdate {
+			if err := addOldInput(d, envelope, meta); err != nil {
+				return nil, err
+			}
+		}
+		return blockInvocation(d, "rollback", block, envelope, data, meta)
+	}, meta)
+	if err != nil {
+		keepTainted(d)
+		return fmt.Errorf("%w\n\nrolling back failed as well: %s", failure, err)
+	}
+	return failure
+}
+
+// keepTainted stores a resource whose creation and rollback failed in state if
+// requested, so that its finalizers run when it gets replaced or destroyed.
+// Terraform marks resources failing on create as tainted.
+func keepTainted(d *schema.ResourceData) {
+	rollbacks := d.Get("rollback").([]interface{})
+	if d.Id() != "" || len(rollbacks) == 0 || !rollbacks[0].(map[string]interface{})["keep_tainted_on_failure"].(bool) {
+		return
+	}
+
+	d.SetId(uuid.New().String())
+	d.Partial(false)
+	if d.Get("conceal_input").(bool) {
+		d.Set("input", "")
+	}
+	d.Set("result", "")
+	log.Printf("[WARN] %s is kept in state as tainted since both its creation and rollback failed\n", d.Id())
+}
+
+// addOldInput adds the input of the resource stored in state, unless it is
+// concealed or the resource is being created.
+func addOldInput(d *schema.ResourceData, envelope map[string]interface{}, meta interface{}) error {
+	if d.Id() == "" || d.Get("conceal_input").(bool) {
+		return nil
+	}
+	old, _ := d.GetChange("input")
+	oldInput, err := mergeDefaultInput(old.(string), meta.(*providerMeta))
+	if err != nil {
+		return err
+	}
+	envelope["old_input"] = parseJSONValue(oldInput)
+	return nil
+}
+
+// blockInvocation describes the invocation of a block with its input wrapped in
+// the given envelope, which carries the input of the main invocation described
+// by main. The payload is concealed like the input of the resource.
+func blockInvocation(d *schema.ResourceData, name string, block map[string]interface{}, envelope map[string]interface{}, main map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	data, err := extractBlockInformation(block, meta)
+	if err != nil {
+		return nil, err
+	}
+	envelope["resource_id"] = d.Id()
+	envelope["input"] = parseJSONValue(data["input"].(string))
+	encryptEnvelopeInputs(data, main["input_encryption"].([]interface{}), envelope)
+	if data["input"], err = marshalJSON(envelope); err != nil {
+		return nil, err
+	}
+	data["conceal_input"] = d.Get("conceal_input").(bool)
+	data["conceal_result"] = d.Get("conceal_result").(bool)
+	data["secret_input"] = data["secret_input"].(bool) || main["secret_input"].(bool)
+	data["request_type"] = name
+	return data, nil
+}
@@ -0,0 +1,237 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateHooksTestConfig(version string) string {
+	return fmt.Sprintf(`
+		resource "lambdabased_resource" "test" {
+			function_name = "install-chart"
+			input = jsonencode({ version = "%s" })
+			pre_update {
+				function_name = "drain-traffic"
+				input = jsonencode({ service = "my-service" })
+			}
+			post_update {
+				function_name = "smoke-test"
+				input = jsonencode({ url = "https://my-service" })
+			}
+			rollback {
+				function_name = "restore-chart"
+				input = jsonencode({ chart = "my-chart" })
+			}
+		}`, version)
+}
+
+func TestLambdaBasedResource_updateHooks(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	var steps []resource.TestStep
+
+	var invoked []string
+	payloads := map[string]map[string]interface{}{}
+	smokeTestFails := false
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			name := aws.ToString(params.FunctionName)
+			invoked = append(invoked, name)
+			payload := map[string]interface{}{}
+			json.Unmarshal(params.Payload, &payload)
+			payloads[name] = payload
+			return createLambdaInvokeOutput(name == "smoke-test" && smokeTestFails), nil
+		}).AnyTimes()
+
+	// Hooks don't run on create
+	steps = append(steps, resource.TestStep{
+		Config: generateHooksTestConfig("1"),
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, []string{"install-chart"}, invoked)
+			return nil
+		},
+	})
+
+	// They run around the update with the old and the new input
+	steps = append(steps, resource.TestStep{
+		PreConfig: func() { invoked = nil },
+		Config:    generateHooksTestConfig("2"),
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, []string{"drain-traffic", "install-chart", "smoke-test"}, invoked)
+			assert.Equal(t, map[string]interface{}{
+				"resource_id": getTestResourceState(s).ID,
+				"input":       map[string]interface{}{"service": "my-service"},
+				"old_input":   map[string]interface{}{"version": "1"},
+				"new_input":   map[string]interface{}{"version": "2"},
+			}, payloads["drain-traffic"])
+			return nil
+		},
+	})
+
+	// A failing post_update rolls back
+	steps = append(steps, resource.TestStep{
+		PreConfig: func() {
+			invoked = nil
+			smokeTestFails = true
+		},
+		Config:      generateHooksTestConfig("3"),
+		ExpectError: regexp.MustCompile(`post_update.0: Lambda function \(smoke-test\) returned error`),
+	})
+	steps = append(steps, resource.TestStep{
+		PreConfig: func() {
+			assert.Equal(t, []string{"drain-traffic", "install-chart", "smoke-test", "restore-chart"}, invoked)
+			assert.Equal(t, "post_update", payloads["restore-chart"]["stage"])
+			assert.Equal(t, map[string]interface{}{"version": "3"}, payloads["restore-chart"]["failed_input"])
+			assert.Equal(t, map[string]interface{}{"version": "2"}, payloads["restore-chart"]["old_input"])
+			smokeTestFails = false
+		},
+		Config:             generateHooksTestConfig("3"),
+		PlanOnly:           true,
+		ExpectNonEmptyPlan: true,
+	})
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps:             steps,
+	})
+}
+
+func TestLambdaBasedResource_addHooks(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	var steps []resource.TestStep
+
+	var invoked []string
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			invoked = append(invoked, aws.ToString(params.FunctionName))
+			return createLambdaInvokeOutput(false), nil
+		}).AnyTimes()
+
+	steps = append(steps, resource.TestStep{
+		Config: `
+			resource "lambdabased_resource" "test" {
+				function_name = "install-chart"
+				input = jsonencode({ version = "1" })
+			}`,
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, []string{"install-chart"}, invoked)
+			return nil
+		},
+	})
+
+	// Adding hooks to an existing resource invokes nothing
+	steps = append(steps, resource.TestStep{
+		PreConfig: func() { invoked = nil },
+		Config: `
+			resource "lambdabased_resource" "test" {
+				function_name = "install-chart"
+				input = jsonencode({ version = "1" })
+				pre_update {
+					function_name = "drain-traffic"
+					input = jsonencode({ service = "my-service" })
+				}
+				post_update {
+					function_name = "smoke-test"
+					input = jsonencode({ url = "https://my-service" })
+				}
+			}`,
+		Check: func(s *terraform.State) error {
+			assert.Empty(t, invoked)
+			assert.Equal(t, "create", getTestResourceState(s).Attributes["planned_invocation"])
+			return nil
+		},
+	})
+
+	// So does adding a rollback
+	steps = append(steps, resource.TestStep{
+		Config: generateHooksTestConfig("1"),
+		Check: func(s *terraform.State) error {
+			assert.Empty(t, invoked)
+			return nil
+		},
+	})
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps:             steps,
+	})
+}
+
+func TestLambdaBasedResource_rollback(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	var steps []resource.TestStep
+
+	var invoked []string
+	payloads := map[string]map[string]interface{}{}
+	failing := map[string]bool{"install-chart": true, "restore-chart": true}
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			name := aws.ToString(params.FunctionName)
+			invoked = append(invoked, name)
+			payload := map[string]interface{}{}
+			json.Unmarshal(params.Payload, &payload)
+			payloads[name] = payload
+			return createLambdaInvokeOutput(failing[name]), nil
+		}).AnyTimes()
+
+	config := `
+		resource "lambdabased_resource" "test" {
+			function_name = "install-chart"
+			input = jsonencode({ chart = "my-chart" })
+			rollback {
+				function_name = "restore-chart"
+				input = jsonencode({ chart = "my-chart" })
+				keep_tainted_on_failure = true
+			}
+			finalizer {
+				function_name = "uninstall-chart"
+				input = jsonencode({ chart = "my-chart" })
+			}
+		}`
+
+	// Both the creation and the rollback fail, the resource is kept as tainted
+	steps = append(steps, resource.TestStep{
+		Config:      config,
+		ExpectError: regexp.MustCompile(`rolling back failed as well`),
+	})
+
+	// It gets replaced, running the finalizer
+	steps = append(steps, resource.TestStep{
+		PreConfig: func() {
+			assert.Equal(t, []string{"install-chart", "restore-chart"}, invoked)
+			assert.Equal(t, "invocation", payloads["restore-chart"]["stage"])
+			assert.Equal(t, "create", payloads["restore-chart"]["request_type"])
+			assert.Equal(t, map[string]interface{}{"chart": "my-chart"}, payloads["restore-chart"]["failed_input"])
+			assert.Contains(t, payloads["restore-chart"]["error"], "Lambda function (install-chart) returned error")
+			invoked, failing = nil, map[string]bool{}
+		},
+		Config: config,
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, []string{"uninstall-chart", "install-chart"}, invoked)
+			return nil
+		},
+	})
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps:             steps,
+	})
+}
@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseJSONObject decodes a JSON object, keeping numbers as json.Number so they
+// survive a decode/encode round trip unchanged. An empty string yields nil.
+func parseJSONObject(raw string) (map[string]interface{}, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.UseNumber()
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("expected a JSON object: %w", err)
+	}
+	return obj, nil
+}
+
+// marshalJSON encodes v without escaping HTML characters, unlike json.Marshal.
+func marshalJSON(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// deepMerge returns a copy of base with overlay merged on top of it. Nested
+// objects are merged recursively, any other value in overlay replaces the one in base.
+func deepMerge(base, overlay map[string]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(base)+len(overlay))
+	for k, v := range base {
+		ret[k] = v
+	}
+	for k, v := range overlay {
+		baseObj, baseIsObj := ret[k].(map[string]interface{})
+		overlayObj, overlayIsObj := v.(map[string]interface{})
+		if baseIsObj && overlayIsObj {
+			ret[k] = deepMerge(baseObj, overlayObj)
+		} else {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// mergeDefaultInput places the provider level default inputs under the given input.
+// The input is returned untouched when no defaults are configured.
+func mergeDefaultInput(input string, meta *providerMeta) (string, error) {
+	if meta.defaultInput == nil && meta.defaultSecretInput == nil {
+		return input, nil
+	}
+	obj, err := parseJSONObject(input)
+	if err != nil {
+		return "", fmt.Errorf("input can't be merged with the provider default input: %w", err)
+	}
+	merged := deepMerge(deepMerge(meta.defaultInput, meta.defaultSecretInput), obj)
+	return marshalJSON(merged)
+}
+
+// defaultInputHash returns the hash of the provider level default_input that is
+// stored in state for resources tracking it. default_secret_input never takes part in it.
+func defaultInputHash(meta *providerMeta) string {
+	if meta.defaultInput == nil {
+		return ""
+	}
+	raw, _ := json.Marshal(meta.defaultInput)
+	return fmt.Sprintf("%x", sha256.Sum256(raw))
+}
+
+// redactSecretInput replaces the values of the keys coming from default_secret_input
+// in the payload, e.g. before logging it.
+func redactSecretInput(payload string, secretInput map[string]interface{}) string {
+	if secretInput == nil {
+		return payload
+	}
+	obj, err := parseJSONObject(payload)
+	if err != nil {
+		return "<redacted>"
+	}
+	ret, _ := marshalJSON(redact(obj, secretInput))
+	return ret
+}
+
+func redact(obj, secret map[string]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		ret[k] = v
+		secretValue, ok := secret[k]
+		if !ok {
+			continue
+		}
+		nestedSecret, secretIsObj := secretValue.(map[string]interface{})
+		nestedObj, objIsObj := v.(map[string]interface{})
+		if secretIsObj && objIsObj {
+			ret[k] = redact(nestedObj, nestedSecret)
+		} else {
+			ret[k] = "<redacted>"
+		}
+	}
+	return ret
+}
+
+// lookupJSONPath resolves a dot separated path such as "cluster.endpoints.0" in
+// a JSON document. Numeric segments index arrays.
+func lookupJSONPath(document string, path string) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(document))
+	dec.UseNumber()
+	var current interface{}
+	if err := dec.Decode(&current); err != nil {
+		return nil, fmt.Errorf("result is not JSON: %w", err)
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			v, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("%q not found in path %q", segment, path)
+			}
+			current = v
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("invalid index %q in path %q", segment, path)
+			}
+			current = node[i]
+		default:
+			return nil, fmt.Errorf("%q can't be resolved in path %q, parent is not an object or an array", segment, path)
+		}
+	}
+	return current, nil
+}
+
+// jsonValueString returns strings as is and encodes any other value as JSON.
+func jsonValueString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	ret, _ := marshalJSON(v)
+	return ret
+}
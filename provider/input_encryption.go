@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"github.com/thetradedesk/terraform-provider-lambdabased/lambdabasedenc"
+)
+
+func inputEncryptionSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"kms_key_id": {
+					Type:         schema.TypeString,
+					Required:     true,
+					ValidateFunc: validation.StringIsNotEmpty,
+				},
+				"fields": {
+					Type:     schema.TypeList,
+					Optional: true,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
+// encryptsInput tells whether the invocation described by data has an
+// input_encryption block.
+func encryptsInput(data map[string]interface{}) bool {
+	inputEncryption, _ := data["input_encryption"].([]interface{})
+	return len(inputEncryption) > 0
+}
+
+// canonicalInput returns the input in the form lambdabasedenc.Decrypt restores
+// it to when fields of it are encrypted, so that the signature made over it
+// verifies against the decrypted payload.
+func canonicalInput(data map[string]interface{}, input string) (string, error) {
+	if !encryptsInput(data) {
+		return input, nil
+	}
+	inputEncryption := data["input_encryption"].([]interface{})[0].(map[string]interface{})
+	if len(inputEncryption["fields"].([]interface{})) == 0 {
+		return input, nil
+	}
+	canonical, err := lambdabasedenc.Canonicalize([]byte(input))
+	if err != nil {
+		return "", fmt.Errorf("input_encryption: input %w", err)
+	}
+	return string(canonical), nil
+}
+
+// envelopeInputKeys are the keys of the block payloads carrying inputs of the
+// resource, see blockInvocation and finalizerInvocation.
+var envelopeInputKeys = []string{"new_input", "old_input", "failed_input", "create_input"}
+
+// encryptEnvelopeInputs makes sure the inputs of the resource carried in the
+// envelope of a block are encrypted if the resource encrypts its own input,
+// whose input_encryption is given. They are encrypted as a whole, along with
+// the block's own input_encryption if it has one, otherwise with the KMS key of
+// the resource.
+func encryptEnvelopeInputs(data map[string]interface{}, resourceEncryption []interface{}, envelope map[string]interface{}) {
+	if len(resourceEncryption) == 0 {
+		return
+	}
+	var keys []string
+	for _, key := range envelopeInputKeys {
+		if _, ok := envelope[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	kmsKeyID := resourceEncryption[0].(map[string]interface{})["kms_key_id"]
+	var fields []interface{}
+	if encryptsInput(data) {
+		own := data["input_encryption"].([]interface{})[0].(map[string]interface{})
+		ownFields := own["fields"].([]interface{})
+		// The whole payload is encrypted anyway
+		if len(ownFields) == 0 {
+			return
+		}
+		kmsKeyID = own["kms_key_id"]
+		// Fields within the inputs are covered by encrypting the inputs
+		for _, field := range ownFields {
+			if !withinAny(field.(string), keys) {
+				fields = append(fields, field)
+			}
+		}
+	}
+	for _, key := range keys {
+		fields = append(fields, key)
+	}
+	data["input_encryption"] = []interface{}{map[string]interface{}{
+		"kms_key_id": kmsKeyID,
+		"fields":     fields,
+	}}
+}
+
+// withinAny tells whether the dot separated path is one of roots or below one.
+func withinAny(path string, roots []string) bool {
+	for _, root := range roots {
+		if path == root || strings.HasPrefix(path, root+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptingClient encrypts the payloads with a KMS data key before passing
+// them to client. It is placed under the recording, so cassettes match the
+// plaintext and replays don't need KMS.
+type encryptingClient struct {
+	client   LambdaClient
+	kms      lambdabasedenc.KMSClient
+	kmsKeyID string
+	fields   []string
+}
+
+func (m *providerMeta) encryptingClient(ctx context.Context, client LambdaClient, data map[string]interface{}) (LambdaClient, error) {
+	if !encryptsInput(data) {
+		return client, nil
+	}
+	inputEncryption := data["input_encryption"].([]interface{})[0].(map[string]interface{})
+
+	kmsClient, err := m.getKMSClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("input_encryption: %w", err)
+	}
+	return &encryptingClient{
+		client:   client,
+		kms:      kmsClient,
+		kmsKeyID: inputEncryption["kms_key_id"].(string),
+		fields:   expandStringList(inputEncryption["fields"].([]interface{})),
+	}, nil
+}
+
+func (c *encryptingClient) Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	// DryRun invocations have no payload
+	if params.InvocationType == lambdatypes.InvocationTypeDryRun {
+		return c.client.Invoke(ctx, params, optFns...)
+	}
+
+	payload, err := lambdabasedenc.Encrypt(ctx, c.kms, c.kmsKeyID, params.Payload, c.fields)
+	if err != nil {
+		return nil, fmt.Errorf("input_encryption: %w", err)
+	}
+	encrypted := *params
+	encrypted.Payload = payload
+	return c.client.Invoke(ctx, &encrypted, optFns...)
+}
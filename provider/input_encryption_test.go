@@ -0,0 +1,280 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thetradedesk/terraform-provider-lambdabased/lambdabasedenc"
+	"github.com/thetradedesk/terraform-provider-lambdabased/lambdabasedsig"
+)
+
+func TestLambdaBasedResource_inputEncryption(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	// The provider talks to the local KMS through the KMS API
+	localKMS := lambdabasedenc.NewLocalKMS("alias/terraform")
+	server := httptest.NewServer(localKMS)
+	defer server.Close()
+
+	var payloads []string
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			assert.NotContains(t, string(params.Payload), "s3cr3t")
+			concealInput, _ := concealmentFromContext(ctx)
+			assert.True(t, concealInput)
+
+			payload, err := lambdabasedenc.Decrypt(ctx, localKMS, params.Payload)
+			if err != nil {
+				return nil, err
+			}
+			payloads = append(payloads, string(payload))
+			return createLambdaInvokeOutput(false), nil
+		}).Times(2)
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		ProviderFactories: createEncryptionProviderFactories(m),
+		CheckDestroy: func(s *terraform.State) error {
+			assert.Equal(t, []string{
+				`{"password":"s3cr3t","user":"admin"}`,
+				`{"user":"admin","password":"s3cr3t"}`,
+			}, payloads)
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+				provider "lambdabased" {
+					kms_endpoint = "%s"
+				}
+				resource "lambdabased_resource" "test" {
+					function_name = "create-user"
+					input = jsonencode({ user = "admin", password = "s3cr3t" })
+					input_encryption {
+						kms_key_id = "alias/terraform"
+						fields = ["password"]
+					}
+					finalizer {
+						function_name = "delete-user"
+						input = "{\"user\":\"admin\",\"password\":\"s3cr3t\"}"
+						input_encryption {
+							kms_key_id = "alias/terraform"
+						}
+					}
+				}`, server.URL),
+			},
+		},
+	})
+}
+
+func TestLambdaBasedResource_inputEncryptionHooks(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	var steps []resource.TestStep
+
+	localKMS := lambdabasedenc.NewLocalKMS("alias/terraform")
+	server := httptest.NewServer(localKMS)
+	defer server.Close()
+
+	// The blocks encrypt the inputs of the resource they carry, even without
+	// input_encryption of their own
+	payloads := map[string]map[string]interface{}{}
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			assert.NotContains(t, string(params.Payload), "s3cr3t")
+			concealInput, _ := concealmentFromContext(ctx)
+			assert.True(t, concealInput)
+
+			decrypted, err := lambdabasedenc.Decrypt(ctx, localKMS, params.Payload)
+			if err != nil {
+				return nil, err
+			}
+			payload := map[string]interface{}{}
+			json.Unmarshal(decrypted, &payload)
+			payloads[aws.ToString(params.FunctionName)] = payload
+			return createLambdaInvokeOutput(false), nil
+		}).AnyTimes()
+
+	config := func(version string) string {
+		return fmt.Sprintf(`
+			provider "lambdabased" {
+				kms_endpoint = "%s"
+			}
+			resource "lambdabased_resource" "test" {
+				function_name = "create-user"
+				input = jsonencode({ user = "admin", password = "s3cr3t", version = "%s" })
+				input_encryption {
+					kms_key_id = "alias/terraform"
+					fields = ["password"]
+				}
+				pre_update {
+					function_name = "drain-traffic"
+					input = jsonencode({ service = "my-service" })
+				}
+				finalizer {
+					function_name = "delete-user"
+					input = jsonencode({ user = "admin" })
+					include_context = true
+				}
+			}`, server.URL, version)
+	}
+
+	steps = append(steps, resource.TestStep{
+		Config: config("1"),
+	})
+	steps = append(steps, resource.TestStep{
+		Config: config("2"),
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, map[string]interface{}{"service": "my-service"}, payloads["drain-traffic"]["input"])
+			assert.Equal(t, map[string]interface{}{"user": "admin", "password": "s3cr3t", "version": "1"}, payloads["drain-traffic"]["old_input"])
+			assert.Equal(t, map[string]interface{}{"user": "admin", "password": "s3cr3t", "version": "2"}, payloads["drain-traffic"]["new_input"])
+			return nil
+		},
+	})
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		ProviderFactories: createEncryptionProviderFactories(m),
+		CheckDestroy: func(s *terraform.State) error {
+			assert.Equal(t, map[string]interface{}{"user": "admin"}, payloads["delete-user"]["input"])
+			assert.Equal(t, map[string]interface{}{"user": "admin", "password": "s3cr3t", "version": "2"}, payloads["delete-user"]["create_input"])
+			return nil
+		},
+		Steps: steps,
+	})
+}
+
+func TestLambdaBasedResource_inputEncryptionSigning(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	localKMS := lambdabasedenc.NewLocalKMS("alias/terraform")
+	server := httptest.NewServer(localKMS)
+	defer server.Close()
+
+	// Handlers decrypt the payload and verify the signature over the result
+	keys := map[string][]byte{"terraform": []byte("signing-secret")}
+	var verified []string
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			payload, err := lambdabasedenc.Decrypt(ctx, localKMS, params.Payload)
+			if err != nil {
+				return nil, err
+			}
+			custom, err := lambdabasedsig.ParseClientContext(aws.ToString(params.ClientContext))
+			if err != nil {
+				return nil, err
+			}
+			if _, err := lambdabasedsig.Verify(custom, payload, keys, time.Minute); err != nil {
+				return nil, err
+			}
+			verified = append(verified, string(payload))
+			return createLambdaInvokeOutput(false), nil
+		}).Times(2)
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		ProviderFactories: createEncryptionProviderFactories(m),
+		CheckDestroy: func(s *terraform.State) error {
+			assert.Equal(t, []string{
+				`{"password":"s3cr3t","user":"a<b","x":1}`,
+				`{"user":"admin", "password":"s3cr3t"}`,
+			}, verified)
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+				provider "lambdabased" {
+					kms_endpoint = "%s"
+					signing {
+						key    = "signing-secret"
+						key_id = "terraform"
+					}
+				}
+				resource "lambdabased_resource" "test" {
+					function_name = "create-user"
+					input = "{\"user\":\"a<b\", \"password\":\"s3cr3t\", \"x\": 1}"
+					input_encryption {
+						kms_key_id = "alias/terraform"
+						fields = ["password"]
+					}
+					finalizer {
+						function_name = "delete-user"
+						input = "{\"user\":\"admin\", \"password\":\"s3cr3t\"}"
+						input_encryption {
+							kms_key_id = "alias/terraform"
+						}
+					}
+				}`, server.URL),
+			},
+		},
+	})
+}
+
+func TestEncryptEnvelopeInputs(t *testing.T) {
+	resourceEncryption := []interface{}{map[string]interface{}{"kms_key_id": "resource-key", "fields": []interface{}{"password"}}}
+	envelope := map[string]interface{}{"input": nil, "old_input": nil, "new_input": nil}
+
+	data := map[string]interface{}{}
+	encryptEnvelopeInputs(data, resourceEncryption, envelope)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"kms_key_id": "resource-key",
+		"fields":     []interface{}{"new_input", "old_input"},
+	}}, data["input_encryption"])
+
+	// The fields of the block within the inputs are covered by them
+	data = map[string]interface{}{"input_encryption": []interface{}{map[string]interface{}{
+		"kms_key_id": "block-key",
+		"fields":     []interface{}{"input.token", "new_input.password"},
+	}}}
+	encryptEnvelopeInputs(data, resourceEncryption, envelope)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"kms_key_id": "block-key",
+		"fields":     []interface{}{"input.token", "new_input", "old_input"},
+	}}, data["input_encryption"])
+
+	// Nothing to add when the block encrypts its whole payload
+	blockEncryption := []interface{}{map[string]interface{}{"kms_key_id": "block-key", "fields": []interface{}{}}}
+	data = map[string]interface{}{"input_encryption": blockEncryption}
+	encryptEnvelopeInputs(data, resourceEncryption, envelope)
+	assert.Equal(t, blockEncryption, data["input_encryption"])
+
+	// Nor when the resource doesn't encrypt its input
+	data = map[string]interface{}{}
+	encryptEnvelopeInputs(data, nil, envelope)
+	assert.Nil(t, data["input_encryption"])
+}
+
+func createEncryptionProviderFactories(lambdaClient LambdaClient) map[string]func() (*schema.Provider, error) {
+	return map[string]func() (*schema.Provider, error){
+		"lambdabased": func() (*schema.Provider, error) {
+			p := createProvider(func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+				meta, diags := newProviderMeta(d, lambdaClient)
+				if meta != nil {
+					meta.loadAWSConfig = func(ctx context.Context) (aws.Config, error) { return createTestAWSConfig(), nil }
+				}
+				return meta, diags
+			})
+			if err := p.Configure(context.Background(), terraform.NewResourceConfigRaw(nil)); err != nil {
+				log.Fatal(err)
+			}
+			return p, nil
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLambdaBasedResource_invokeOnCreate(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	var steps []resource.TestStep
+
+	configParam := newConfigParameters()
+	configParam.FinalizerBlockOn = false
+	configParam.ExtraAttributes = `invoke_on = ["create"]`
+
+	// A finalizer would never be invoked
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(func() configParameters {
+			cp := configParam
+			cp.FinalizerBlockOn = true
+			return cp
+		}()),
+		PlanOnly:    true,
+		ExpectError: regexp.MustCompile(`finalizer blocks are never invoked`),
+	})
+
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, false)).Return(createLambdaInvokeOutput(false), nil)
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(configParam),
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, "create", getTestResourceState(s).Attributes["planned_invocation"])
+			return nil
+		},
+	})
+
+	// Changes are stored without invoking
+	configParam.Input = "createupdate-input-param-val-2"
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(configParam),
+		Check: func(s *terraform.State) error {
+			rs := getTestResourceState(s)
+			assert.Equal(t, "none", rs.Attributes["planned_invocation"])
+			assert.Equal(t, getInputJson("createupdate-input-param-val-2"), rs.Attributes["input"])
+			assert.Equal(t, "result-val", rs.Attributes["result"])
+			return nil
+		},
+	})
+
+	// Changing invoke_on alone doesn't invoke either, even once update is in it
+	configParam.ExtraAttributes = ""
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(configParam),
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, "none", getTestResourceState(s).Attributes["planned_invocation"])
+			return nil
+		},
+	})
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps:             steps,
+	})
+}
+
+func TestLambdaBasedResource_invokeOnDelete(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	configParam := newConfigParameters()
+	configParam.ExtraAttributes = `invoke_on = ["delete"]`
+
+	// Only the finalizer is invoked
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, true)).Return(createLambdaInvokeOutput(false), nil)
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps: []resource.TestStep{
+			{
+				Config: generateTestConfig(configParam),
+				Check: func(s *terraform.State) error {
+					rs := getTestResourceState(s)
+					assert.Equal(t, "none", rs.Attributes["planned_invocation"])
+					assert.Equal(t, "", rs.Attributes["result"])
+					return nil
+				},
+			},
+		},
+	})
+}
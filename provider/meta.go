@@ -0,0 +1,221 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/thetradedesk/terraform-provider-lambdabased/lambdabasedenc"
+)
+
+type providerMeta struct {
+	defaultInput       map[string]interface{}
+	defaultSecretInput map[string]interface{}
+	secrets            map[string]string
+	limiter            *invocationLimiter
+	invocations        *invocationCache
+	cassette           *cassette
+	signing            *signingKey
+	kmsEndpoint        string
+	dryRun             bool
+	verifyOnPlan       bool
+	dryRunResult       string
+
+	// loadAWSConfig resolves the AWS configuration including credentials. It is
+	// called the first time an AWS client is needed, and again on later calls
+	// until it succeeds, so that a transient failure isn't cached.
+	loadAWSConfig func(ctx context.Context) (aws.Config, error)
+	awsConfigMu   sync.Mutex
+	awsConfig     *aws.Config
+
+	// clientsMu guards the clients below, which are only set once built.
+	clientsMu           sync.Mutex
+	client              LambdaClient
+	functionURLClient   LambdaClient
+	stepFunctionsClient LambdaClient
+	functionClient      FunctionClient
+	kmsClient           lambdabasedenc.KMSClient
+}
+
+// newProviderMeta parses the provider configuration. If client is nil, it gets
+// built from the AWS configuration on first use.
+func newProviderMeta(d *schema.ResourceData, client LambdaClient) (*providerMeta, diag.Diagnostics) {
+	meta := &providerMeta{
+		client:       client,
+		invocations:  newInvocationCache(),
+		dryRun:       d.Get("dry_run").(bool),
+		dryRunResult: d.Get("dry_run_result").(string),
+		verifyOnPlan: d.Get("verify_on_plan").(bool),
+		kmsEndpoint:  d.Get("kms_endpoint").(string),
+	}
+	var err error
+	if meta.defaultInput, err = parseJSONObject(d.Get("default_input").(string)); err != nil {
+		return nil, diag.Errorf("default_input: %s", err)
+	}
+	if meta.defaultSecretInput, err = parseJSONObject(d.Get("default_secret_input").(string)); err != nil {
+		return nil, diag.Errorf("default_secret_input: %s", err)
+	}
+
+	meta.secrets = map[string]string{}
+	for name, secret := range d.Get("secrets").(map[string]interface{}) {
+		meta.secrets[name] = secret.(string)
+	}
+
+	if concurrencyRaw, ok := d.GetOk("concurrency"); ok {
+		concurrency := concurrencyRaw.([]interface{})[0].(map[string]interface{})
+		perFunction := map[string]int{}
+		for name, limit := range concurrency["per_function"].(map[string]interface{}) {
+			perFunction[name] = limit.(int)
+		}
+		meta.limiter = newInvocationLimiter(concurrency["default_limit"].(int), perFunction)
+	}
+
+	if signingRaw, ok := d.GetOk("signing"); ok {
+		signing := signingRaw.([]interface{})[0].(map[string]interface{})
+		meta.signing = &signingKey{
+			key:       []byte(signing["key"].(string)),
+			keyID:     signing["key_id"].(string),
+			algorithm: signing["algorithm"].(string),
+		}
+	}
+
+	if recordingRaw, ok := d.GetOk("recording"); ok {
+		recording := recordingRaw.([]interface{})[0].(map[string]interface{})
+		if meta.cassette, err = newCassette(recording["mode"].(string), recording["path"].(string)); err != nil {
+			return nil, diag.FromErr(err)
+		}
+	}
+	return meta, nil
+}
+
+func (m *providerMeta) getAWSConfig(ctx context.Context) (aws.Config, error) {
+	m.awsConfigMu.Lock()
+	defer m.awsConfigMu.Unlock()
+	if m.awsConfig != nil {
+		return *m.awsConfig, nil
+	}
+	if m.loadAWSConfig == nil {
+		return aws.Config{}, errors.New("AWS configuration is not available")
+	}
+	cfg, err := m.loadAWSConfig(ctx)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	m.awsConfig = &cfg
+	return cfg, nil
+}
+
+func (m *providerMeta) getLambdaClient(ctx context.Context) (LambdaClient, error) {
+	m.clientsMu.Lock()
+	defer m.clientsMu.Unlock()
+	if m.client != nil {
+		return m.client, nil
+	}
+	cfg, err := m.getAWSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.client = lambda.NewFromConfig(cfg)
+	return m.client, nil
+}
+
+// getFunctionURLClient returns the client invoking function urls, which are
+// passed as the function name.
+func (m *providerMeta) getFunctionURLClient(ctx context.Context) (LambdaClient, error) {
+	m.clientsMu.Lock()
+	defer m.clientsMu.Unlock()
+	if m.functionURLClient != nil {
+		return m.functionURLClient, nil
+	}
+	cfg, err := m.getAWSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.functionURLClient = newFunctionURLBackend(cfg)
+	return m.functionURLClient, nil
+}
+
+// getStepFunctionsClient returns the client running state machines, whose ARNs
+// are passed as the function name.
+func (m *providerMeta) getStepFunctionsClient(ctx context.Context) (LambdaClient, error) {
+	m.clientsMu.Lock()
+	defer m.clientsMu.Unlock()
+	if m.stepFunctionsClient != nil {
+		return m.stepFunctionsClient, nil
+	}
+	cfg, err := m.getAWSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.stepFunctionsClient = newStepFunctionsBackend(sfn.NewFromConfig(cfg))
+	return m.stepFunctionsClient, nil
+}
+
+// getFunctionClient returns the client describing functions. It always talks
+// to AWS Lambda, regardless of the backend.
+func (m *providerMeta) getFunctionClient(ctx context.Context) (FunctionClient, error) {
+	m.clientsMu.Lock()
+	defer m.clientsMu.Unlock()
+	if m.functionClient != nil {
+		return m.functionClient, nil
+	}
+	cfg, err := m.getAWSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.functionClient = lambda.NewFromConfig(cfg)
+	return m.functionClient, nil
+}
+
+// getKMSClient returns the client generating the data keys of input_encryption.
+func (m *providerMeta) getKMSClient(ctx context.Context) (lambdabasedenc.KMSClient, error) {
+	m.clientsMu.Lock()
+	defer m.clientsMu.Unlock()
+	if m.kmsClient != nil {
+		return m.kmsClient, nil
+	}
+	cfg, err := m.getAWSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.kmsClient = kms.NewFromConfig(cfg, func(o *kms.Options) {
+		if m.kmsEndpoint != "" {
+			o.EndpointResolver = kms.EndpointResolverFromURL(m.kmsEndpoint)
+		}
+	})
+	return m.kmsClient, nil
+}
+
+// invocationTarget returns the name to be passed as the function name and the
+// client to invoke the function, state machine or function url described in data.
+func (m *providerMeta) invocationTarget(ctx context.Context, data map[string]interface{}) (string, LambdaClient, error) {
+	name, getClient := data["function_name"].(string), m.getLambdaClient
+	if functionURL, _ := data["function_url"].(string); functionURL != "" {
+		name, getClient = functionURL, m.getFunctionURLClient
+	} else if stateMachineArn, _ := data["state_machine_arn"].(string); stateMachineArn != "" {
+		name, getClient = stateMachineArn, m.getStepFunctionsClient
+	}
+
+	if m.cassette != nil && m.cassette.mode == recordingModeReplay {
+		return name, m.cassette, nil
+	}
+
+	client, err := getClient(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if client, err = m.encryptingClient(ctx, client, data); err != nil {
+		return "", nil, err
+	}
+	if m.cassette != nil {
+		client = m.cassette.wrap(client)
+	}
+	return name, client, nil
+}
@@ -0,0 +1,76 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/thetradedesk/terraform-provider-lambdabased/provider (interfaces: FunctionClient)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	lambda "github.com/aws/aws-sdk-go-v2/service/lambda"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockFunctionClient is a mock of FunctionClient interface.
+type MockFunctionClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockFunctionClientMockRecorder
+}
+
+// MockFunctionClientMockRecorder is the mock recorder for MockFunctionClient.
+type MockFunctionClientMockRecorder struct {
+	mock *MockFunctionClient
+}
+
+// NewMockFunctionClient creates a new mock instance.
+func NewMockFunctionClient(ctrl *gomock.Controller) *MockFunctionClient {
+	mock := &MockFunctionClient{ctrl: ctrl}
+	mock.recorder = &MockFunctionClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFunctionClient) EXPECT() *MockFunctionClientMockRecorder {
+	return m.recorder
+}
+
+// GetAlias mocks base method.
+func (m *MockFunctionClient) GetAlias(arg0 context.Context, arg1 *lambda.GetAliasInput, arg2 ...func(*lambda.Options)) (*lambda.GetAliasOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetAlias", varargs...)
+	ret0, _ := ret[0].(*lambda.GetAliasOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAlias indicates an expected call of GetAlias.
+func (mr *MockFunctionClientMockRecorder) GetAlias(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAlias", reflect.TypeOf((*MockFunctionClient)(nil).GetAlias), varargs...)
+}
+
+// GetFunction mocks base method.
+func (m *MockFunctionClient) GetFunction(arg0 context.Context, arg1 *lambda.GetFunctionInput, arg2 ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetFunction", varargs...)
+	ret0, _ := ret[0].(*lambda.GetFunctionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFunction indicates an expected call of GetFunction.
+func (mr *MockFunctionClientMockRecorder) GetFunction(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFunction", reflect.TypeOf((*MockFunctionClient)(nil).GetFunction), varargs...)
+}
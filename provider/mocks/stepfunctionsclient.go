@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/thetradedesk/terraform-provider-lambdabased/provider (interfaces: StepFunctionsClient)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	sfn "github.com/aws/aws-sdk-go-v2/service/sfn"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStepFunctionsClient is a mock of StepFunctionsClient interface.
+type MockStepFunctionsClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockStepFunctionsClientMockRecorder
+}
+
+// MockStepFunctionsClientMockRecorder is the mock recorder for MockStepFunctionsClient.
+type MockStepFunctionsClientMockRecorder struct {
+	mock *MockStepFunctionsClient
+}
+
+// NewMockStepFunctionsClient creates a new mock instance.
+func NewMockStepFunctionsClient(ctrl *gomock.Controller) *MockStepFunctionsClient {
+	mock := &MockStepFunctionsClient{ctrl: ctrl}
+	mock.recorder = &MockStepFunctionsClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStepFunctionsClient) EXPECT() *MockStepFunctionsClientMockRecorder {
+	return m.recorder
+}
+
+// DescribeExecution mocks base method.
+func (m *MockStepFunctionsClient) DescribeExecution(arg0 context.Context, arg1 *sfn.DescribeExecutionInput, arg2 ...func(*sfn.Options)) (*sfn.DescribeExecutionOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DescribeExecution", varargs...)
+	ret0, _ := ret[0].(*sfn.DescribeExecutionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeExecution indicates an expected call of DescribeExecution.
+func (mr *MockStepFunctionsClientMockRecorder) DescribeExecution(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeExecution", reflect.TypeOf((*MockStepFunctionsClient)(nil).DescribeExecution), varargs...)
+}
+
+// GetExecutionHistory mocks base method.
+func (m *MockStepFunctionsClient) GetExecutionHistory(arg0 context.Context, arg1 *sfn.GetExecutionHistoryInput, arg2 ...func(*sfn.Options)) (*sfn.GetExecutionHistoryOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetExecutionHistory", varargs...)
+	ret0, _ := ret[0].(*sfn.GetExecutionHistoryOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExecutionHistory indicates an expected call of GetExecutionHistory.
+func (mr *MockStepFunctionsClientMockRecorder) GetExecutionHistory(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExecutionHistory", reflect.TypeOf((*MockStepFunctionsClient)(nil).GetExecutionHistory), varargs...)
+}
+
+// StartExecution mocks base method.
+func (m *MockStepFunctionsClient) StartExecution(arg0 context.Context, arg1 *sfn.StartExecutionInput, arg2 ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "StartExecution", varargs...)
+	ret0, _ := ret[0].(*sfn.StartExecutionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartExecution indicates an expected call of StartExecution.
+func (mr *MockStepFunctionsClientMockRecorder) StartExecution(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartExecution", reflect.TypeOf((*MockStepFunctionsClient)(nil).StartExecution), varargs...)
+}
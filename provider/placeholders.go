@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// placeholderPattern matches placeholders such as ${result.release.name}.
+var placeholderPattern = regexp.MustCompile(`\$\{(\w+)(?:[.:]([^}]*))?\}`)
+
+// placeholderResolver returns the value of a placeholder of the given kind. It
+// returns false for kinds it doesn't know, which are left untouched.
+type placeholderResolver func(kind, path string) (interface{}, bool, error)
+
+// resolvePlaceholders replaces the placeholders in the string values of a JSON
+// document. A value consisting of a single placeholder is replaced by the
+// resolved value as is, otherwise the resolved values are embedded as text.
+func resolvePlaceholders(input string, resolve placeholderResolver) (string, error) {
+	if !placeholderPattern.MatchString(input) {
+		return input, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader([]byte(input)))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return "", err
+	}
+	doc, err := resolvePlaceholderValues(doc, resolve)
+	if err != nil {
+		return "", err
+	}
+	return marshalJSON(doc)
+}
+
+func resolvePlaceholderValues(v interface{}, resolve placeholderResolver) (interface{}, error) {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			resolved, err := resolvePlaceholderValues(child, resolve)
+			if err != nil {
+				return nil, err
+			}
+			node[k] = resolved
+		}
+	case []interface{}:
+		for i, child := range node {
+			resolved, err := resolvePlaceholderValues(child, resolve)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = resolved
+		}
+	case string:
+		if m := placeholderPattern.FindStringSubmatch(node); m != nil && m[0] == node {
+			resolved, ok, err := resolve(m[1], m[2])
+			if err != nil || !ok {
+				return node, err
+			}
+			return resolved, nil
+		}
+
+		var resolveErr error
+		ret := placeholderPattern.ReplaceAllStringFunc(node, func(placeholder string) string {
+			m := placeholderPattern.FindStringSubmatch(placeholder)
+			resolved, ok, err := resolve(m[1], m[2])
+			if err != nil && resolveErr == nil {
+				resolveErr = err
+			}
+			if err != nil || !ok {
+				return placeholder
+			}
+			return jsonValueString(resolved)
+		})
+		return ret, resolveErr
+	}
+	return v, nil
+}
+
+// resultResolver resolves ${result} and ${result.<path>} placeholders from the
+// last result of the resource.
+func resultResolver(result string, concealed bool) placeholderResolver {
+	return func(kind, path string) (interface{}, bool, error) {
+		if kind != "result" {
+			return nil, false, nil
+		}
+		if concealed {
+			return nil, true, fmt.Errorf("${result} placeholders can't be resolved since the result is concealed")
+		}
+		if path == "" {
+			return parseJSONValue(result), true, nil
+		}
+		v, err := lookupJSONPath(result, path)
+		if err != nil {
+			return nil, true, fmt.Errorf("${result.%s}: %w", path, err)
+		}
+		return v, true, nil
+	}
+}
+
+// parseJSONValue decodes a JSON document keeping the numbers as they are. It
+// returns the document as a string if it isn't JSON.
+func parseJSONValue(raw string) interface{} {
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return raw
+	}
+	return v
+}
+
+// secretResolver resolves ${env:<name>} placeholders from the environment of
+// the provider and ${provider:<name>} placeholders from the secrets in the
+// provider configuration. resolved is set once a placeholder is resolved.
+func secretResolver(meta *providerMeta, resolved *bool) placeholderResolver {
+	return func(kind, name string) (interface{}, bool, error) {
+		switch kind {
+		case "env":
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				return nil, true, fmt.Errorf("${env:%s}: environment variable is not set", name)
+			}
+			*resolved = true
+			return v, true, nil
+		case "provider":
+			v, ok := meta.secrets[name]
+			if !ok {
+				return nil, true, fmt.Errorf("${provider:%s}: no such secret in the provider configuration", name)
+			}
+			*resolved = true
+			return v, true, nil
+		}
+		return nil, false, nil
+	}
+}
+
+// resolveSecrets resolves the secret placeholders in the input of an invocation.
+// Inputs with secrets are concealed like the ones merged with default_secret_input.
+func resolveSecrets(data map[string]interface{}, meta *providerMeta) error {
+	resolved := false
+	input, err := resolvePlaceholders(data["input"].(string), secretResolver(meta, &resolved))
+	if err != nil {
+		return err
+	}
+	data["input"] = input
+	data["secret_input"] = resolved
+	return nil
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLambdaBasedResource_secretPlaceholders(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	os.Setenv("LAMBDABASED_TEST_TOKEN", "env-token-val")
+	defer os.Unsetenv("LAMBDABASED_TEST_TOKEN")
+
+	var finalizerPayload string
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			if aws.ToString(params.FunctionName) == "uninstall-chart" {
+				finalizerPayload = string(params.Payload)
+				concealInput, _ := concealmentFromContext(ctx)
+				assert.True(t, concealInput)
+			}
+			return createLambdaInvokeOutput(false), nil
+		}).Times(2)
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		CheckDestroy: func(s *terraform.State) error {
+			assert.JSONEq(t, `{"chart":"my-chart","token":"provider-token-val","auth":"Bearer env-token-val"}`, finalizerPayload)
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				provider "lambdabased" {
+					secrets = {
+						cluster_token = "provider-token-val"
+					}
+				}
+
+				resource "lambdabased_resource" "test" {
+					function_name = "install-chart"
+					input = jsonencode({ chart = "my-chart" })
+					finalizer {
+						function_name = "uninstall-chart"
+						input = jsonencode({
+							chart = "my-chart"
+							token = "$${provider:cluster_token}"
+							auth  = "Bearer $${env:LAMBDABASED_TEST_TOKEN}"
+						})
+					}
+				}`,
+				Check: func(s *terraform.State) error {
+					// Only the placeholders are stored
+					input := getTestResourceState(s).Attributes["finalizer.0.input"]
+					assert.Contains(t, input, "${provider:cluster_token}")
+					assert.NotContains(t, input, "token-val")
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestResolveSecrets(t *testing.T) {
+	meta := &providerMeta{secrets: map[string]string{"token": "t"}}
+
+	data := map[string]interface{}{"input": `{"param":"p"}`}
+	assert.NoError(t, resolveSecrets(data, meta))
+	assert.Equal(t, false, data["secret_input"])
+
+	data = map[string]interface{}{"input": `{"token":"${provider:token}"}`}
+	assert.NoError(t, resolveSecrets(data, meta))
+	assert.Equal(t, `{"token":"t"}`, data["input"])
+	assert.Equal(t, true, data["secret_input"])
+
+	assert.Error(t, resolveSecrets(map[string]interface{}{"input": `{"token":"${provider:missing}"}`}, meta))
+	assert.Error(t, resolveSecrets(map[string]interface{}{"input": `{"token":"${env:LAMBDABASED_MISSING}"}`}, meta))
+}
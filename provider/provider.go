@@ -2,20 +2,50 @@ package provider
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
-	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"github.com/thetradedesk/terraform-provider-lambdabased/lambdabasedsig"
 )
 
 func Provider() *schema.Provider {
 	return createProvider(providerConfigure)
 }
 
+// ProviderWithClient returns the provider invoking everything through the given
+// client, including function urls and state machines, instead of AWS. Functions
+// are described through the client too if it implements FunctionClient. It is
+// meant for tests, see the lambdabasedtest package.
+func ProviderWithClient(client LambdaClient) *schema.Provider {
+	return createProvider(func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+		meta, diags := newProviderMeta(d, client)
+		if diags.HasError() {
+			return nil, diags
+		}
+		meta.functionURLClient = client
+		meta.stepFunctionsClient = client
+		if functionClient, ok := client.(FunctionClient); ok {
+			meta.functionClient = functionClient
+		}
+		return meta, diags
+	})
+}
+
 func createProvider(configureContextFunc schema.ConfigureContextFunc) *schema.Provider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
@@ -29,6 +59,63 @@ func createProvider(configureContextFunc schema.ConfigureContextFunc) *schema.Pr
 				Optional: true,
 				Default:  "",
 			},
+			"access_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"secret_key"},
+			},
+			"secret_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"access_key"},
+			},
+			"token": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"access_key", "secret_key"},
+			},
+			"shared_config_files": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"shared_credentials_files": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"http_proxy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https"}),
+			},
+			"custom_ca_bundle": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"insecure": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"http_timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+			},
+			"skip_credentials_validation": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
 			"assume_role": {
 				Type:     schema.TypeList,
 				Optional: true,
@@ -42,10 +129,188 @@ func createProvider(configureContextFunc schema.ConfigureContextFunc) *schema.Pr
 					},
 				},
 			},
+			"backend": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      backendAWSLambda,
+				ValidateFunc: validation.StringInSlice([]string{backendAWSLambda, backendHTTP, backendExec}, false),
+			},
+			"http_backend": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"url": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.IsURLWithScheme([]string{"http", "https"}),
+						},
+						"headers": {
+							Type:      schema.TypeMap,
+							Optional:  true,
+							Sensitive: true,
+							Elem:      &schema.Schema{Type: schema.TypeString},
+						},
+						"bearer_token": {
+							Type:          schema.TypeString,
+							Optional:      true,
+							Sensitive:     true,
+							ConflictsWith: []string{"http_backend.0.basic_auth"},
+						},
+						"basic_auth": {
+							Type:     schema.TypeList,
+							Optional: true,
+							MaxItems: 1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"username": {
+										Type:     schema.TypeString,
+										Required: true,
+									},
+									"password": {
+										Type:      schema.TypeString,
+										Required:  true,
+										Sensitive: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"exec_backend": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"command": {
+							Type:     schema.TypeList,
+							Required: true,
+							MinItems: 1,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"env": {
+							Type:      schema.TypeMap,
+							Optional:  true,
+							Sensitive: true,
+							Elem:      &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"concurrency": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"default_limit": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      0,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"per_function": {
+							Type:     schema.TypeMap,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeInt},
+						},
+					},
+				},
+			},
+			"recording": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"mode": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice([]string{recordingModeRecord, recordingModeReplay}, false),
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"verify_on_plan": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"dry_run": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("LAMBDABASED_DRY_RUN", false),
+			},
+			"dry_run_result": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"default_input": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"default_secret_input": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"secrets": {
+				Type:      schema.TypeMap,
+				Optional:  true,
+				Sensitive: true,
+				Elem:      &schema.Schema{Type: schema.TypeString},
+			},
+			"kms_endpoint": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https"}),
+			},
+			"signing": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:         schema.TypeString,
+							Required:     true,
+							Sensitive:    true,
+							ValidateFunc: validation.StringIsNotEmpty,
+						},
+						"key_id": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "",
+						},
+						"algorithm": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      lambdabasedsig.AlgorithmHMACSHA256,
+							ValidateFunc: validation.StringInSlice([]string{lambdabasedsig.AlgorithmHMACSHA256, lambdabasedsig.AlgorithmHMACSHA512}, false),
+						},
+					},
+				},
+			},
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"lambdabased_resource": LambdaBasedResource(),
+			"lambdabased_resource":     LambdaBasedResource(),
+			"lambdabased_resource_set": LambdaBasedResourceSet(),
+		},
+
+		DataSourcesMap: map[string]*schema.Resource{
+			"lambdabased_invocation": LambdaBasedInvocationDataSource(),
+			"lambdabased_function":   LambdaBasedFunctionDataSource(),
 		},
 
 		ConfigureContextFunc: configureContextFunc,
@@ -53,22 +318,142 @@ func createProvider(configureContextFunc schema.ConfigureContextFunc) *schema.Pr
 }
 
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithSharedConfigProfile(d.Get("profile").(string)),
-		config.WithRegion(d.Get("region").(string)),
-	)
-
+	opts, err := loadOptions(d)
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
 
+	role := ""
 	if assumeRoleRaw, ok := d.GetOk("assume_role"); ok {
 		assumeRole := assumeRoleRaw.([]interface{})[0]
-		role := assumeRole.(map[string]interface{})["role_arn"].(string)
-		stsSvc := sts.NewFromConfig(cfg)
-		creds := stscreds.NewAssumeRoleProvider(stsSvc, role)
-		cfg.Credentials = aws.NewCredentialsCache(creds)
+		role = assumeRole.(map[string]interface{})["role_arn"].(string)
+	}
+	skipCredentialsValidation := d.Get("skip_credentials_validation").(bool)
+
+	client, err := newBackend(d)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	meta, diags := newProviderMeta(d, client)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	// Credentials are resolved on first use so that plans not invoking any function work offline
+	meta.loadAWSConfig = func(ctx context.Context) (aws.Config, error) {
+		cfg, err := config.LoadDefaultConfig(ctx, opts...)
+		if err != nil {
+			return aws.Config{}, err
+		}
+
+		if role != "" {
+			stsSvc := sts.NewFromConfig(cfg)
+			creds := stscreds.NewAssumeRoleProvider(stsSvc, role)
+			cfg.Credentials = aws.NewCredentialsCache(creds)
+		}
+
+		if !skipCredentialsValidation {
+			if _, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+				return aws.Config{}, fmt.Errorf("validating provider credentials: %w", err)
+			}
+		}
+		return cfg, nil
+	}
+	return meta, nil
+}
+
+func loadOptions(d *schema.ResourceData) ([]func(*config.LoadOptions) error, error) {
+	opts := []func(*config.LoadOptions) error{
+		config.WithSharedConfigProfile(d.Get("profile").(string)),
+		config.WithRegion(d.Get("region").(string)),
+	}
+
+	if accessKey := d.Get("access_key").(string); accessKey != "" {
+		creds := credentials.NewStaticCredentialsProvider(accessKey, d.Get("secret_key").(string), d.Get("token").(string))
+		opts = append(opts, config.WithCredentialsProvider(creds))
+	}
+	if files := expandStringList(d.Get("shared_config_files").([]interface{})); len(files) > 0 {
+		opts = append(opts, config.WithSharedConfigFiles(files))
+	}
+	if files := expandStringList(d.Get("shared_credentials_files").([]interface{})); len(files) > 0 {
+		opts = append(opts, config.WithSharedCredentialsFiles(files))
+	}
+	// GetOk doesn't tell 0, which disables the retries, from not set. The raw
+	// config isn't available to the provider configuration either.
+	if maxRetries, ok := d.GetOkExists("max_retries"); ok {
+		// max_retries doesn't count the initial attempt
+		opts = append(opts, config.WithRetryMaxAttempts(maxRetries.(int)+1))
 	}
 
-	return lambda.NewFromConfig(cfg), nil
+	httpClient, err := buildHTTPClient(d)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, config.WithHTTPClient(httpClient))
+	return opts, nil
+}
+
+// buildHTTPClient returns the http client shared by all AWS clients of the provider
+// as well as the http backend.
+func buildHTTPClient(d *schema.ResourceData) (*awshttp.BuildableClient, error) {
+	client := awshttp.NewBuildableClient()
+
+	if caBundle := d.Get("custom_ca_bundle").(string); caBundle != "" {
+		pem, err := ioutil.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("reading custom_ca_bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("custom_ca_bundle %s doesn't contain any PEM encoded certificate", caBundle)
+		}
+		client = client.WithTransportOptions(func(tr *http.Transport) {
+			if tr.TLSClientConfig == nil {
+				tr.TLSClientConfig = &tls.Config{}
+			}
+			tr.TLSClientConfig.RootCAs = pool
+		})
+	}
+
+	if proxy := d.Get("http_proxy").(string); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("parsing http_proxy: %w", err)
+		}
+		client = client.WithTransportOptions(func(tr *http.Transport) {
+			tr.Proxy = http.ProxyURL(proxyURL)
+		})
+	}
+	if d.Get("insecure").(bool) {
+		client = client.WithTransportOptions(func(tr *http.Transport) {
+			if tr.TLSClientConfig == nil {
+				tr.TLSClientConfig = &tls.Config{}
+			}
+			tr.TLSClientConfig.InsecureSkipVerify = true
+		})
+	}
+	if timeout := d.Get("http_timeout").(string); timeout != "" {
+		duration, _ := time.ParseDuration(timeout) // already validated
+		client = client.WithTimeout(duration)
+	}
+	return client, nil
+}
+
+func validateDuration(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a duration such as \"30s\" or \"2m\": %w", k, err))
+	}
+	return
+}
+
+func expandStringList(list []interface{}) []string {
+	ret := make([]string, 0, len(list))
+	for _, v := range list {
+		ret = append(ret, v.(string))
+	}
+	return ret
 }
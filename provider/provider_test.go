@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider(t *testing.T) {
+	assert.NoError(t, Provider().InternalValidate())
+}
+
+func TestProvider_explicitCredentials(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"region":      "us-east-1",
+		"access_key":  "access-key-val",
+		"secret_key":  "secret-key-val",
+		"token":       "token-val",
+		"max_retries": 3,
+	})
+
+	opts, err := loadOptions(d)
+	assert.NoError(t, err)
+	cfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+	assert.NoError(t, err)
+
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "access-key-val", creds.AccessKeyID)
+	assert.Equal(t, "secret-key-val", creds.SecretAccessKey)
+	assert.Equal(t, "token-val", creds.SessionToken)
+	assert.Equal(t, 4, cfg.RetryMaxAttempts)
+}
+
+func TestProvider_maxRetries(t *testing.T) {
+	for _, tc := range []struct {
+		raw      map[string]interface{}
+		attempts int
+	}{
+		{raw: map[string]interface{}{}, attempts: 0}, // the SDK's default
+		{raw: map[string]interface{}{"max_retries": 0}, attempts: 1},
+		{raw: map[string]interface{}{"max_retries": 2}, attempts: 3},
+	} {
+		d := schema.TestResourceDataRaw(t, Provider().Schema, tc.raw)
+
+		opts, err := loadOptions(d)
+		assert.NoError(t, err)
+		cfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.attempts, cfg.RetryMaxAttempts)
+	}
+}
+
+func TestProvider_httpSettings(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"http_proxy":   "http://proxy.example.com:3128",
+		"insecure":     true,
+		"http_timeout": "45s",
+	})
+
+	client, err := buildHTTPClient(d)
+	assert.NoError(t, err)
+	assert.Equal(t, 45*time.Second, client.GetTimeout())
+
+	tr := client.GetTransport()
+	assert.True(t, tr.TLSClientConfig.InsecureSkipVerify)
+	req, _ := http.NewRequest("GET", "https://lambda.us-east-1.amazonaws.com", nil)
+	proxy, err := tr.Proxy(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "proxy.example.com:3128", proxy.Host)
+}
+
+func TestProvider_lazyCredentials(t *testing.T) {
+	// No credentials may come from the environment the tests run in
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE",
+		"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+	} {
+		t.Setenv(name, "")
+	}
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	assert.NoError(t, ioutil.WriteFile(emptyFile, nil, 0600))
+	raw := map[string]interface{}{
+		"region":                   "us-east-1",
+		"shared_config_files":      []interface{}{emptyFile},
+		"shared_credentials_files": []interface{}{emptyFile},
+	}
+
+	// Configuring doesn't touch credentials
+	meta, diags := providerConfigure(context.Background(), schema.TestResourceDataRaw(t, Provider().Schema, raw))
+	assert.False(t, diags.HasError())
+
+	// They are resolved when a client is needed
+	_, err := meta.(*providerMeta).getLambdaClient(context.Background())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "validating provider credentials")
+	}
+
+	// The failure isn't cached, subsequent calls try again
+	_, err2 := meta.(*providerMeta).getLambdaClient(context.Background())
+	assert.Equal(t, err.Error(), err2.Error())
+
+	// Unless the validation is skipped, then it's up to the invocation to fail
+	raw["skip_credentials_validation"] = true
+	meta, diags = providerConfigure(context.Background(), schema.TestResourceDataRaw(t, Provider().Schema, raw))
+	assert.False(t, diags.HasError())
+	client, err := meta.(*providerMeta).getLambdaClient(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, client)
+}
+
+func TestProvider_awsConfigRetry(t *testing.T) {
+	calls := 0
+	meta := &providerMeta{
+		loadAWSConfig: func(ctx context.Context) (aws.Config, error) {
+			calls++
+			if calls == 1 {
+				return aws.Config{}, errors.New("transient error")
+			}
+			return createTestAWSConfig(), nil
+		},
+	}
+
+	_, err := meta.getLambdaClient(context.Background())
+	assert.EqualError(t, err, "transient error")
+
+	client, err := meta.getLambdaClient(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, client)
+
+	// Once loaded, the configuration is reused by all the clients
+	_, err = meta.getFunctionClient(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
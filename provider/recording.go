@@ -0,0 +1,181 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+const (
+	recordingModeRecord = "record"
+	recordingModeReplay = "replay"
+)
+
+type cassetteRequest struct {
+	FunctionName   string `json:"function_name"`
+	Qualifier      string `json:"qualifier"`
+	InvocationType string `json:"invocation_type"`
+	PayloadSHA256  string `json:"payload_sha256"`
+	Payload        string `json:"payload,omitempty"`
+}
+
+type cassetteResponse struct {
+	StatusCode    int32  `json:"status_code,omitempty"`
+	FunctionError string `json:"function_error,omitempty"`
+	Payload       string `json:"payload,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+type cassetteInteraction struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+type cassetteFile struct {
+	Interactions []cassetteInteraction `json:"interactions"`
+}
+
+// cassette records invocations to, or replays them from, a file. Payloads are
+// matched by their hash so concealed inputs can be replayed without being written.
+type cassette struct {
+	mode string
+	path string
+
+	mu           sync.Mutex
+	interactions []cassetteInteraction
+	used         []bool
+}
+
+func newCassette(mode, path string) (*cassette, error) {
+	c := &cassette{mode: mode, path: path}
+
+	raw, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && mode == recordingModeRecord {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+
+	var file cassetteFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+	}
+	c.interactions = file.Interactions
+	c.used = make([]bool, len(c.interactions))
+	return c, nil
+}
+
+// wrap returns a client recording the invocations made through the given client.
+func (c *cassette) wrap(client LambdaClient) LambdaClient {
+	return &recordingClient{cassette: c, client: client}
+}
+
+type recordingClient struct {
+	cassette *cassette
+	client   LambdaClient
+}
+
+func (r *recordingClient) Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	res, err := r.client.Invoke(ctx, params, optFns...)
+
+	concealInput, concealResult := concealmentFromContext(ctx)
+	interaction := cassetteInteraction{Request: newCassetteRequest(params, concealInput)}
+	if err != nil {
+		interaction.Response.Error = err.Error()
+	} else {
+		interaction.Response.StatusCode = res.StatusCode
+		interaction.Response.FunctionError = aws.ToString(res.FunctionError)
+		if !concealResult {
+			interaction.Response.Payload = string(res.Payload)
+		}
+	}
+
+	if saveErr := r.cassette.append(interaction); saveErr != nil {
+		return nil, saveErr
+	}
+	return res, err
+}
+
+func (c *cassette) append(interaction cassetteInteraction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = append(c.interactions, interaction)
+
+	// Saved on every invocation since there is no hook to flush it when the provider exits
+	raw, err := json.MarshalIndent(cassetteFile{Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(c.path, raw, 0600); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
+
+// Invoke answers from the recorded interactions. Interactions are consumed in the
+// recorded order, the last matching one is reused once all of them are consumed.
+func (c *cassette) Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	req := newCassetteRequest(params, true)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	match := -1
+	for i, interaction := range c.interactions {
+		if !interaction.Request.matches(req) {
+			continue
+		}
+		match = i
+		if !c.used[i] {
+			break
+		}
+	}
+	if match == -1 {
+		return nil, fmt.Errorf("cassette %s has no recorded invocation of %s (qualifier: %s, invocation type: %s) with payload sha256 %s",
+			c.path, req.FunctionName, req.Qualifier, req.InvocationType, req.PayloadSHA256)
+	}
+	c.used[match] = true
+
+	res := c.interactions[match].Response
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+	ret := &lambda.InvokeOutput{StatusCode: res.StatusCode, Payload: []byte(res.Payload)}
+	if res.FunctionError != "" {
+		ret.FunctionError = aws.String(res.FunctionError)
+	}
+	return ret, nil
+}
+
+func newCassetteRequest(params *lambda.InvokeInput, concealPayload bool) cassetteRequest {
+	invocationType := params.InvocationType
+	if invocationType == "" {
+		invocationType = lambdatypes.InvocationTypeRequestResponse
+	}
+	req := cassetteRequest{
+		FunctionName:   aws.ToString(params.FunctionName),
+		Qualifier:      aws.ToString(params.Qualifier),
+		InvocationType: string(invocationType),
+		PayloadSHA256:  fmt.Sprintf("%x", sha256.Sum256(params.Payload)),
+	}
+	if !concealPayload {
+		req.Payload = string(params.Payload)
+	}
+	return req
+}
+
+func (r cassetteRequest) matches(other cassetteRequest) bool {
+	return r.FunctionName == other.FunctionName &&
+		r.Qualifier == other.Qualifier &&
+		r.InvocationType == other.InvocationType &&
+		r.PayloadSHA256 == other.PayloadSHA256
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLambdaBasedResource_recordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	configParam := newConfigParameters()
+	configParam.ConcealInput = true
+
+	// Record with a real (mock) client
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, false)).Return(createLambdaInvokeOutput(false), nil)
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, true)).Return(createLambdaInvokeOutput(false), nil)
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		CheckDestroy: func(s *terraform.State) error {
+			raw, err := ioutil.ReadFile(path)
+			assert.NoError(t, err)
+			cassette := string(raw)
+			assert.NotContains(t, cassette, "createupdate-input-param-val") // input is concealed
+			assert.Contains(t, cassette, "destroy-input-param-val")
+			assert.Contains(t, cassette, "result-val")
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{Config: generateRecordingTestConfig("record", path, configParam)},
+		},
+	})
+
+	// Replay without any client
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(nil),
+		Steps: []resource.TestStep{
+			{
+				Config: generateRecordingTestConfig("replay", path, configParam),
+				Check: func(s *terraform.State) error {
+					assert.Equal(t, "result-val", getTestResourceState(s).Attributes["result"])
+					return nil
+				},
+			},
+			{
+				Config: generateRecordingTestConfig("replay", path, func() configParameters {
+					cp := configParam
+					cp.TriggerParameter = "trigger-now"
+					cp.Input = "an-unrecorded-input-val"
+					return cp
+				}()),
+				ExpectError: regexp.MustCompile("has no recorded invocation of func-createupdate-name-1"),
+			},
+		},
+	})
+}
+
+func generateRecordingTestConfig(mode, path string, params configParameters) string {
+	return fmt.Sprintf(`
+		provider "lambdabased" {
+			recording {
+				mode = "%s"
+				path = "%s"
+			}
+		}`, mode, path) + generateTestConfig(params)
+}
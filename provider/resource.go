@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
@@ -13,10 +14,6 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
-type LambdaClient interface {
-	Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error)
-}
-
 func LambdaBasedResource() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceCreateUpdate,
@@ -24,10 +21,22 @@ func LambdaBasedResource() *schema.Resource {
 		Update: resourceCreateUpdate,
 		Delete: resourceDelete,
 
+		CustomizeDiff: resourceCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			"function_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"function_name", "function_url", "state_machine_arn"},
+			},
+			"function_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https"}),
+			},
+			"state_machine_arn": {
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
 			},
 			"qualifier": {
 				Type:     schema.TypeString,
@@ -54,46 +63,319 @@ func LambdaBasedResource() *schema.Resource {
 				Optional: true,
 				Default:  false,
 			},
+			"verify_on_plan": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"trigger_on_default_input": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"trigger_on_function_change": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ValidateFunc:  validation.StringInSlice([]string{functionChangeCode, functionChangeVersion, functionChangeConfig}, false),
+				ConflictsWith: []string{"function_url", "state_machine_arn"},
+			},
+			"eks_auth":         eksAuthSchema(),
+			"input_encryption": inputEncryptionSchema(),
+			"invoke_on": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice([]string{requestTypeCreate, requestTypeUpdate, requestTypeDelete}, false),
+				},
+			},
+			"deletion_protection": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"finalizer_on_destroy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      finalizerOnDestroyRun,
+				ValidateFunc: validation.StringInSlice([]string{finalizerOnDestroyRun, finalizerOnDestroySkip}, false),
+			},
 			"finalizer": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: invocationBlock(map[string]*schema.Schema{
+					"include_context": {
+						Type:     schema.TypeBool,
+						Optional: true,
+						Default:  false,
+					},
+				}),
+			},
+			"pre_update": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     invocationBlock(nil),
+			},
+			"post_update": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     invocationBlock(nil),
+			},
+			"rollback": {
 				Type:     schema.TypeList,
 				Optional: true,
 				MaxItems: 1,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"function_name": {
-							Type:     schema.TypeString,
-							Required: true,
-						},
-						"qualifier": {
-							Type:     schema.TypeString,
-							Optional: true,
-							Default:  "$LATEST",
-						},
-						"input": {
-							Type:         schema.TypeString,
-							Required:     true,
-							ValidateFunc: validation.StringIsJSON,
-						},
+				Elem: invocationBlock(map[string]*schema.Schema{
+					"keep_tainted_on_failure": {
+						Type:     schema.TypeBool,
+						Optional: true,
+						Default:  false,
 					},
-				},
+				}),
 			},
 			"result": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"default_input_hash": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"dry_run_pending": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"function_fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"planned_invocation": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 		},
 	}
 }
 
+// invocationBlock describes the functions invoked by the finalizer, pre_update,
+// post_update and rollback blocks, with the given extra arguments.
+func invocationBlock(extra map[string]*schema.Schema) *schema.Resource {
+	ret := &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"function_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"function_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https"}),
+			},
+			"state_machine_arn": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"qualifier": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "$LATEST",
+			},
+			"input": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"on_failure": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      onFailureFail,
+				ValidateFunc: validation.StringInSlice([]string{onFailureFail, onFailureContinue, onFailureRetry}, false),
+			},
+			"retry_attempts": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"ignore_not_found": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"eks_auth":         eksAuthSchema(),
+			"input_encryption": inputEncryptionSchema(),
+		},
+	}
+	for k, v := range extra {
+		ret.Schema[k] = v
+	}
+	return ret
+}
+
+const (
+	requestTypeCreate = "create"
+	requestTypeUpdate = "update"
+	requestTypeDelete = "delete"
+)
+
+// invokesOn tells whether the resource invokes its functions on the given
+// request type according to invoke_on. An empty invoke_on lists all of them.
+func invokesOn(invokeOn *schema.Set, requestType string) bool {
+	return invokeOn.Len() == 0 || invokeOn.Contains(requestType)
+}
+
+// diffPlannedInvocation shows in the plan whether applying it invokes the
+// lambda function, and rejects finalizers that would never be invoked.
+func diffPlannedInvocation(d *schema.ResourceDiff) error {
+	if finalizers, ok := d.GetOk("finalizer"); ok && len(finalizers.([]interface{})) > 0 && !invokesOn(d.Get("invoke_on").(*schema.Set), requestTypeDelete) {
+		return fmt.Errorf("finalizer blocks are never invoked since invoke_on doesn't contain %q", requestTypeDelete)
+	}
+
+	requestType := requestTypeCreate
+	if d.Id() != "" && !createdInDryRun(d) {
+		requestType = requestTypeUpdate
+		if !hasChangesExcept(d, untrackedKeys("planned_invocation")...) && !trackedValueChanged(d) {
+			return nil
+		}
+	}
+
+	planned := requestType
+	if !d.NewValueKnown("invoke_on") {
+		return d.SetNewComputed("planned_invocation")
+	} else if !invokesOn(d.Get("invoke_on").(*schema.Set), requestType) {
+		planned = "none"
+	}
+	if d.Get("planned_invocation").(string) != planned {
+		return d.SetNew("planned_invocation", planned)
+	}
+	return nil
+}
+
+// createdInDryRun tells whether the resource was created in dry run mode and
+// hasn't been created for real since, so that it is still to be created.
+func createdInDryRun(d interface {
+	GetChange(string) (interface{}, interface{})
+}) bool {
+	pending, _ := d.GetChange("dry_run_pending")
+	planned, _ := d.GetChange("planned_invocation")
+	return pending.(bool) && planned.(string) == requestTypeCreate
+}
+
+// noInvokeArguments only affect how the resource is planned, updated or
+// destroyed, changing them doesn't invoke the lambda function.
+var noInvokeArguments = []string{
+	"deletion_protection",
+	"finalizer_on_destroy",
+	"invoke_on",
+	"post_update",
+	"pre_update",
+	"rollback",
+	"trigger_on_default_input",
+	"trigger_on_function_change",
+	"verify_on_plan",
+}
+
+// trackingAttributes hold the values tracked by the trigger arguments they are
+// keyed by.
+var trackingAttributes = map[string]string{
+	"default_input_hash":   "trigger_on_default_input",
+	"function_fingerprint": "trigger_on_function_change",
+}
+
+// trackedValueChanged tells whether a tracked value changed, as opposed to its
+// tracking being turned on, off or into another mode.
+func trackedValueChanged(d interface {
+	GetChange(string) (interface{}, interface{})
+	HasChange(string) bool
+}) bool {
+	for attribute, argument := range trackingAttributes {
+		old, new := d.GetChange(attribute)
+		if old != new && !d.HasChange(argument) {
+			return true
+		}
+	}
+	return false
+}
+
+// untrackedKeys are the keys whose changes alone don't invoke the lambda function.
+func untrackedKeys(keys ...string) []string {
+	ret := append(keys, noInvokeArguments...)
+	for attribute := range trackingAttributes {
+		ret = append(ret, attribute)
+	}
+	return ret
+}
+
+// hasChangesExcept is the ResourceDiff counterpart of ResourceData.HasChangesExcept.
+func hasChangesExcept(d *schema.ResourceDiff, keys ...string) bool {
+	for _, key := range d.GetChangedKeysPrefix("") {
+		root := strings.SplitN(key, ".", 2)[0]
+		excepted := false
+		for _, k := range keys {
+			excepted = excepted || root == k
+		}
+		if !excepted && d.HasChange(root) {
+			return true
+		}
+	}
+	return false
+}
+
 func resourceCreateUpdate(d *schema.ResourceData, meta interface{}) error {
+	if d.Id() != "" && !d.HasChangesExcept(untrackedKeys()...) && !trackedValueChanged(d) {
+		return nil
+	}
+
 	d.Partial(true)
 	concealInput := d.Get("conceal_input").(bool)
 	concealResult := d.Get("conceal_result").(bool)
 
-	res, err := callLambda(d.Id(), extractLambdaInformation(d), meta)
+	data, err := extractLambdaInformation(d, meta)
 	if err != nil {
 		return err
 	}
+	update := d.Id() != "" && !createdInDryRun(d)
+	data["request_type"] = requestTypeUpdate
+	if !update {
+		data["request_type"] = requestTypeCreate
+	}
+
+	if !invokesOn(d.Get("invoke_on").(*schema.Set), data["request_type"].(string)) {
+		log.Printf("[INFO] %s: %s isn't in invoke_on, the changes are stored without invoking\n", d.Id(), data["request_type"])
+		if d.Id() == "" {
+			d.SetId(uuid.New().String())
+		}
+		d.Partial(false)
+		if concealInput {
+			d.Set("input", "")
+		}
+		d.Set("planned_invocation", "none")
+		return nil
+	}
+
+	if update {
+		if err := runUpdateHooks(d, "pre_update", data, meta); err != nil {
+			return err
+		}
+	}
+
+	res, err := callLambda(d.Id(), data, meta)
+	if err != nil {
+		return rollback(d, "invocation", data, err, meta)
+	}
+
+	if update {
+		if err := runUpdateHooks(d, "post_update", data, meta); err != nil {
+			return rollback(d, "post_update", data, err, meta)
+		}
+	}
+
+	// The function may not have been known during plan
+	fingerprint := d.Get("function_fingerprint").(string)
+	if mode := d.Get("trigger_on_function_change").(string); mode != "" && fingerprint == "" {
+		if fingerprint, err = currentFunctionFingerprint(context.TODO(), data["function_name"].(string), data["qualifier"].(string), mode, meta.(*providerMeta)); err != nil {
+			return err
+		}
+	}
 
 	if d.Id() == "" {
 		d.SetId(uuid.New().String())
@@ -107,6 +389,10 @@ func resourceCreateUpdate(d *schema.ResourceData, meta interface{}) error {
 	} else {
 		d.Set("result", string(res))
 	}
+	d.Set("default_input_hash", trackedDefaultInputHash(d.Get("trigger_on_default_input").(bool), meta))
+	d.Set("dry_run_pending", meta.(*providerMeta).dryRun)
+	d.Set("function_fingerprint", fingerprint)
+	d.Set("planned_invocation", data["request_type"])
 
 	d.Partial(false)
 	return nil
@@ -117,26 +403,103 @@ func resourceRead(d *schema.ResourceData, meta interface{}) error {
 }
 
 func resourceDelete(d *schema.ResourceData, meta interface{}) error {
-	concealResult := d.Get("conceal_result").(bool)
-	if destroyRaw, ok := d.GetOk("finalizer"); ok {
-		destroy := destroyRaw.([]interface{})
-		if len(destroy) > 0 {
-			res, err := callLambda(d.Id(), destroy[0].(map[string]interface{}), meta)
-			if err != nil {
-				return err
-			}
-			if !concealResult {
-				log.Printf("%s received destroy response: %s\n", d.Id(), string(res))
+	if d.Get("deletion_protection").(bool) {
+		return fmt.Errorf("%s can't be destroyed since deletion_protection is enabled, set it to false and apply before destroying", d.Id())
+	}
+
+	if d.Get("finalizer_on_destroy").(string) == finalizerOnDestroySkip || !invokesOn(d.Get("invoke_on").(*schema.Set), requestTypeDelete) {
+		log.Printf("[WARN] %s is removed from state without running its finalizers\n", d.Id())
+	} else if err := runFinalizers(d, meta); err != nil {
+		return err
+	}
+
+	if meta.(*providerMeta).dryRun {
+		return fmt.Errorf("dry run: %s is not destroyed, it is kept in state", d.Id())
+	}
+	d.SetId("")
+	return nil
+}
+
+func resourceCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	for _, block := range invocationBlocks {
+		if err := validateBlockTargets(block, d.GetRawConfig().GetAttr(block)); err != nil {
+			return err
+		}
+	}
+
+	hash := trackedDefaultInputHash(d.Get("trigger_on_default_input").(bool), meta)
+	if d.Get("default_input_hash").(string) != hash {
+		if err := d.SetNew("default_input_hash", hash); err != nil {
+			return err
+		}
+	}
+
+	// Resources applied in dry run mode are invoked for real once dry run is off
+	if d.Get("dry_run_pending").(bool) && !meta.(*providerMeta).dryRun {
+		if err := d.SetNew("dry_run_pending", false); err != nil {
+			return err
+		}
+	}
+
+	if err := diffFunctionFingerprint(ctx, d, meta.(*providerMeta)); err != nil {
+		return err
+	}
+
+	if err := diffPlannedInvocation(d); err != nil {
+		return err
+	}
+
+	return verifyOnPlan(ctx, d, meta.(*providerMeta))
+}
+
+// diffFunctionFingerprint plans an update when the deployed function tracked by
+// trigger_on_function_change has changed since the last invocation.
+func diffFunctionFingerprint(ctx context.Context, d *schema.ResourceDiff, meta *providerMeta) error {
+	fingerprint := ""
+	if mode := d.Get("trigger_on_function_change").(string); mode != "" {
+		if !d.NewValueKnown("function_name") || !d.NewValueKnown("qualifier") {
+			return d.SetNewComputed("function_fingerprint")
+		}
+		var err error
+		if fingerprint, err = currentFunctionFingerprint(ctx, d.Get("function_name").(string), d.Get("qualifier").(string), mode, meta); err != nil {
+			// The function may be created in the same apply, it is looked up again then
+			if d.Id() == "" && isNotFound(err) {
+				return d.SetNewComputed("function_fingerprint")
 			}
+			return fmt.Errorf("trigger_on_function_change: %w", err)
 		}
 	}
-	d.SetId("")
+
+	if d.Get("function_fingerprint").(string) != fingerprint {
+		return d.SetNew("function_fingerprint", fingerprint)
+	}
 	return nil
 }
 
-func extractLambdaInformation(d *schema.ResourceData) map[string]interface{} {
+func currentFunctionFingerprint(ctx context.Context, functionName, qualifier, mode string, meta *providerMeta) (string, error) {
+	client, err := meta.getFunctionClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	function, err := describeFunction(ctx, client, functionName, qualifier)
+	if err != nil {
+		return "", err
+	}
+	return functionFingerprint(function, mode), nil
+}
+
+// trackedDefaultInputHash returns the hash of default_input if the resource
+// opted in to be updated when it changes, otherwise an empty string.
+func trackedDefaultInputHash(track bool, meta interface{}) string {
+	if !track {
+		return ""
+	}
+	return defaultInputHash(meta.(*providerMeta))
+}
+
+func extractLambdaInformation(d *schema.ResourceData, meta interface{}) (map[string]interface{}, error) {
 	ret := map[string]interface{}{}
-	for _, param := range []string{"function_name", "qualifier", "input"} {
+	for _, param := range []string{"function_name", "function_url", "state_machine_arn", "qualifier", "input"} {
 		attr := d.GetRawConfig().GetAttr(param)
 		if !attr.IsNull() {
 			// Using raw config because input is wiped from regular config if concealed (see DiffSuppressFunc of input field)
@@ -145,21 +508,125 @@ func extractLambdaInformation(d *schema.ResourceData) map[string]interface{} {
 			ret[param] = d.Get(param)
 		}
 	}
-	return ret
+
+	input, err := mergeDefaultInput(ret["input"].(string), meta.(*providerMeta))
+	if err != nil {
+		return nil, err
+	}
+	ret["input"] = input
+	if err := resolveSecrets(ret, meta.(*providerMeta)); err != nil {
+		return nil, err
+	}
+	ret["conceal_input"] = d.Get("conceal_input").(bool)
+	ret["conceal_result"] = d.Get("conceal_result").(bool)
+	ret["eks_auth"] = d.Get("eks_auth")
+	ret["input_encryption"] = d.Get("input_encryption")
+	return ret, nil
+}
+
+// extractBlockInformation describes the invocation of a finalizer, pre_update,
+// post_update or rollback block.
+func extractBlockInformation(block map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	ret := map[string]interface{}{}
+	for k, v := range block {
+		ret[k] = v
+	}
+
+	input, err := mergeDefaultInput(ret["input"].(string), meta.(*providerMeta))
+	if err != nil {
+		return nil, err
+	}
+	ret["input"] = input
+	if err := resolveSecrets(ret, meta.(*providerMeta)); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func logDryRun(id string, data map[string]interface{}, meta *providerMeta) {
+	target := data["function_name"].(string)
+	for _, param := range []string{"function_url", "state_machine_arn"} {
+		if v, _ := data[param].(string); v != "" {
+			target = v
+		}
+	}
+
+	payload := "<concealed>"
+	concealInput, _ := data["conceal_input"].(bool)
+	if secretInput, _ := data["secret_input"].(bool); !concealInput && !secretInput && !encryptsInput(data) {
+		payload = redactSecretInput(data["input"].(string), meta.defaultSecretInput)
+	}
+	log.Printf("[WARN] dry run: %s would invoke %s (qualifier: %s, request type: %s) with payload: %s\n",
+		id, target, data["qualifier"], data["request_type"], payload)
+}
+
+type concealmentKey struct{}
+
+type concealment struct {
+	input  bool
+	result bool
+}
+
+// concealmentFromContext tells whether the payload and the result of the invocation
+// are concealed, so that they aren't written anywhere else either.
+func concealmentFromContext(ctx context.Context) (bool, bool) {
+	c, _ := ctx.Value(concealmentKey{}).(concealment)
+	return c.input, c.result
 }
 
 func callLambda(id string, data map[string]interface{}, meta interface{}) ([]byte, error) {
-	conn := meta.(LambdaClient)
+	if meta.(*providerMeta).dryRun {
+		logDryRun(id, data, meta.(*providerMeta))
+		return []byte(meta.(*providerMeta).dryRunResult), nil
+	}
+
+	concealInput, _ := data["conceal_input"].(bool)
+	concealResult, _ := data["conceal_result"].(bool)
+	secretInput, _ := data["secret_input"].(bool)
+	// Payloads merged with the secret defaults, containing secrets or to be encrypted are treated as concealed as well
+	ctx := context.WithValue(context.TODO(), concealmentKey{}, concealment{
+		input:  concealInput || secretInput || injectsEKSToken(data) || encryptsInput(data) || meta.(*providerMeta).defaultSecretInput != nil,
+		result: concealResult,
+	})
 
-	functionName := data["function_name"].(string)
 	qualifier := data["qualifier"].(string)
-	input := []byte(data["input"].(string))
 
-	res, err := conn.Invoke(context.TODO(), &lambda.InvokeInput{
+	functionName, conn, err := meta.(*providerMeta).invocationTarget(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("Lambda Invocation (%s) failed: %w", id, err)
+	}
+
+	release, err := meta.(*providerMeta).limiter.acquire(ctx, functionName)
+	if err != nil {
+		return nil, fmt.Errorf("Lambda Invocation (%s) failed: %w", id, err)
+	}
+	defer release()
+
+	// EKS tokens are minted for every invocation and never stored. Like the
+	// signature, they are made once queued invocations are let through, so
+	// that they don't expire while waiting.
+	input, err := injectEKSToken(ctx, data, meta.(*providerMeta))
+	if err != nil {
+		return nil, fmt.Errorf("Lambda Invocation (%s) failed: %w", id, err)
+	}
+
+	if input, err = canonicalInput(data, input); err != nil {
+		return nil, fmt.Errorf("Lambda Invocation (%s) failed: %w", id, err)
+	}
+
+	// Queued invocations are signed once they are let through, so that their
+	// timestamp isn't stale when they reach the function
+	clientContext, err := meta.(*providerMeta).signing.clientContext([]byte(input))
+	if err != nil {
+		return nil, fmt.Errorf("Lambda Invocation (%s) failed: %w", id, err)
+	}
+
+	res, err := conn.Invoke(ctx, &lambda.InvokeInput{
 		FunctionName:   aws.String(functionName),
 		InvocationType: lambdatypes.InvocationTypeRequestResponse,
-		Payload:        input,
+		Payload:        []byte(input),
 		Qualifier:      aws.String(qualifier),
+		ClientContext:  clientContext,
 	})
 
 	if err != nil {
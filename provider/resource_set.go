@@ -0,0 +1,259 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func LambdaBasedResourceSet() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSetCreate,
+		Read:          resourceRead,
+		Update:        resourceSetCreateUpdate,
+		Delete:        resourceSetDelete,
+
+		Schema: map[string]*schema.Schema{
+			"function_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"function_name", "function_url", "state_machine_arn"},
+			},
+			"function_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https"}),
+			},
+			"state_machine_arn": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"qualifier": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "$LATEST",
+			},
+			"items": {
+				Type:         schema.TypeMap,
+				Required:     true,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				ValidateFunc: validateJSONMap,
+			},
+			"batch_size": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"results": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+// itemChange is a single item of a batch sent to the function.
+type itemChange struct {
+	Key         string          `json:"key"`
+	RequestType string          `json:"request_type"`
+	Input       json.RawMessage `json:"input"`
+}
+
+// itemResult is the outcome of a single item reported by the function.
+type itemResult struct {
+	Result json.RawMessage `json:"result"`
+	Error  string          `json:"error"`
+}
+
+// resourceSetCreate reports items failing on create as a warning once others
+// were applied. Terraform would taint the set on an error, destroying the
+// applied items on the next apply instead of retrying the failed ones.
+func resourceSetCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	err := resourceSetCreateUpdate(d, meta)
+	if err != nil && d.Id() != "" {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Some items failed, they are retried on the next apply",
+			Detail:   err.Error(),
+		}}
+	}
+	return diag.FromErr(err)
+}
+
+func resourceSetCreateUpdate(d *schema.ResourceData, meta interface{}) error {
+	old, new := d.GetChange("items")
+	changes, err := diffItems(old.(map[string]interface{}), new.(map[string]interface{}), meta)
+	if err != nil {
+		return err
+	}
+
+	// Only the items applied successfully are stored, the others are retried next time
+	applied, results, err := applyItems(d, changes, meta)
+	if d.Id() == "" && len(applied) == 0 && err != nil {
+		return err
+	}
+	if d.Id() == "" {
+		d.SetId(uuid.New().String())
+	}
+	d.Set("items", applied)
+	d.Set("results", results)
+	return err
+}
+
+func resourceSetDelete(d *schema.ResourceData, meta interface{}) error {
+	changes, err := diffItems(d.Get("items").(map[string]interface{}), map[string]interface{}{}, meta)
+	if err != nil {
+		return err
+	}
+
+	applied, results, err := applyItems(d, changes, meta)
+	if err != nil {
+		d.Set("items", applied)
+		d.Set("results", results)
+		return err
+	}
+
+	if meta.(*providerMeta).dryRun {
+		return fmt.Errorf("dry run: %s is not destroyed, it is kept in state", d.Id())
+	}
+	d.SetId("")
+	return nil
+}
+
+// diffItems returns the added, changed and removed items sorted by key. Removed
+// items are sent with their last applied input.
+func diffItems(old, new map[string]interface{}, meta interface{}) ([]itemChange, error) {
+	var ret []itemChange
+	for key, input := range new {
+		oldInput, ok := old[key]
+		switch {
+		case !ok:
+			ret = append(ret, itemChange{Key: key, RequestType: "create", Input: json.RawMessage(input.(string))})
+		case oldInput != input:
+			ret = append(ret, itemChange{Key: key, RequestType: "update", Input: json.RawMessage(input.(string))})
+		}
+	}
+	for key, input := range old {
+		if _, ok := new[key]; !ok {
+			ret = append(ret, itemChange{Key: key, RequestType: "delete", Input: json.RawMessage(input.(string))})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Key < ret[j].Key })
+
+	for i := range ret {
+		input, err := mergeDefaultInput(string(ret[i].Input), meta.(*providerMeta))
+		if err != nil {
+			return nil, fmt.Errorf("items.%s: %w", ret[i].Key, err)
+		}
+		ret[i].Input = json.RawMessage(input)
+	}
+	return ret, nil
+}
+
+// applyItems invokes the function for the changed items in batches and returns
+// the items and the results that are in effect afterwards. Errors of the
+// individual items are collected into a single error.
+func applyItems(d *schema.ResourceData, changes []itemChange, meta interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	old, new := d.GetChange("items")
+	applied := map[string]interface{}{}
+	for key, input := range old.(map[string]interface{}) {
+		applied[key] = input
+	}
+	results := map[string]interface{}{}
+	for key, result := range d.Get("results").(map[string]interface{}) {
+		results[key] = result
+	}
+
+	batchSize := d.Get("batch_size").(int)
+	var failures []string
+	for start := 0; start < len(changes); start += batchSize {
+		end := start + batchSize
+		if end > len(changes) {
+			end = len(changes)
+		}
+		batch := changes[start:end]
+
+		outcomes, err := invokeBatch(d, batch, meta)
+		// Dry runs only log the batches, the items stay unapplied so that they
+		// are invoked for real once dry run is turned off
+		if err == nil && meta.(*providerMeta).dryRun {
+			continue
+		}
+		for _, change := range batch {
+			outcome, ok := outcomes[change.Key]
+			switch {
+			case err != nil:
+				failures = append(failures, fmt.Sprintf("items.%s: %s", change.Key, err))
+			case !ok:
+				failures = append(failures, fmt.Sprintf("items.%s: no result returned for the item", change.Key))
+			case outcome.Error != "":
+				failures = append(failures, fmt.Sprintf("items.%s: %s", change.Key, outcome.Error))
+			case change.RequestType == "delete":
+				delete(applied, change.Key)
+				delete(results, change.Key)
+			default:
+				applied[change.Key] = new.(map[string]interface{})[change.Key]
+				results[change.Key] = outcome.result()
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return applied, results, fmt.Errorf("%d of %d item(s) failed:\n%s", len(failures), len(changes), strings.Join(failures, "\n"))
+	}
+	return applied, results, nil
+}
+
+func invokeBatch(d *schema.ResourceData, batch []itemChange, meta interface{}) (map[string]itemResult, error) {
+	payload, err := marshalJSON(map[string]interface{}{"items": batch})
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{
+		"input":        payload,
+		"request_type": "batch",
+	}
+	for _, param := range []string{"function_name", "function_url", "state_machine_arn", "qualifier"} {
+		data[param] = d.Get(param)
+	}
+
+	res, err := callLambda(d.Id(), data, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.(*providerMeta).dryRun {
+		return nil, nil
+	}
+	ret := map[string]itemResult{}
+	if err := json.Unmarshal(res, &ret); err != nil {
+		return nil, fmt.Errorf("result is not a JSON object keyed by item: %w", err)
+	}
+	return ret, nil
+}
+
+// result returns string results as is and any other result JSON encoded.
+func (r itemResult) result() string {
+	var s string
+	if json.Unmarshal(r.Result, &s) == nil {
+		return s
+	}
+	return string(r.Result)
+}
+
+func validateJSONMap(v interface{}, k string) (ws []string, errors []error) {
+	for key, value := range v.(map[string]interface{}) {
+		w, e := validation.StringIsJSON(value, fmt.Sprintf("%s.%s", k, key))
+		ws, errors = append(ws, w...), append(errors, e...)
+	}
+	return
+}
@@ -0,0 +1,197 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateResourceSetTestConfig(batchSize int, items string) string {
+	return fmt.Sprintf(`
+		resource "lambdabased_resource_set" "test" {
+			function_name = "namespaces"
+			batch_size = %d
+			items = {%s}
+		}`, batchSize, items)
+}
+
+func TestLambdaBasedResourceSet(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	var steps []resource.TestStep
+
+	var batches []string
+	failing := map[string]bool{"c": true}
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			var payload struct{ Items []itemChange }
+			if err := json.Unmarshal(params.Payload, &payload); err != nil {
+				return nil, err
+			}
+			results := map[string]interface{}{}
+			batch := ""
+			for _, item := range payload.Items {
+				batch += fmt.Sprintf("%s:%s ", item.RequestType, item.Key)
+				if failing[item.Key] {
+					results[item.Key] = map[string]string{"error": "quota-exceeded"}
+				} else {
+					results[item.Key] = map[string]interface{}{"result": map[string]string{"name": item.Key}}
+				}
+			}
+			batches = append(batches, batch)
+			res, _ := json.Marshal(results)
+			return &lambda.InvokeOutput{Payload: res}, nil
+		}).AnyTimes()
+
+	// Created in a single batch
+	steps = append(steps, resource.TestStep{
+		Config: generateResourceSetTestConfig(2, `a = jsonencode({ v = 1 }), b = jsonencode({ v = 1 })`),
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, []string{"create:a create:b "}, batches)
+			rs := s.RootModule().Resources["lambdabased_resource_set.test"].Primary
+			assert.Equal(t, `{"name":"a"}`, rs.Attributes["results.a"])
+			return nil
+		},
+	})
+
+	// Only the changed items are applied, one at a time, and failing items are retried
+	steps = append(steps, resource.TestStep{
+		PreConfig:   func() { batches = nil },
+		Config:      generateResourceSetTestConfig(1, `b = jsonencode({ v = 2 }), c = jsonencode({ v = 1 })`),
+		ExpectError: regexp.MustCompile(`items.c: quota-exceeded`),
+	})
+	steps = append(steps, resource.TestStep{
+		PreConfig: func() {
+			assert.Equal(t, []string{"delete:a ", "update:b ", "create:c "}, batches)
+			batches, failing = nil, map[string]bool{}
+		},
+		Config: generateResourceSetTestConfig(1, `b = jsonencode({ v = 2 }), c = jsonencode({ v = 1 })`),
+		Check: func(s *terraform.State) error {
+			assert.Equal(t, []string{"create:c "}, batches)
+			rs := s.RootModule().Resources["lambdabased_resource_set.test"].Primary
+			assert.Equal(t, `{"name":"c"}`, rs.Attributes["results.c"])
+			assert.Empty(t, rs.Attributes["results.a"])
+			return nil
+		},
+	})
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		CheckDestroy: func(s *terraform.State) error {
+			assert.Equal(t, []string{"delete:b ", "delete:c "}, batches[len(batches)-2:])
+			return nil
+		},
+		Steps: steps,
+	})
+}
+
+func TestLambdaBasedResourceSet_dryRun(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	var batches []string
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			var payload struct{ Items []itemChange }
+			if err := json.Unmarshal(params.Payload, &payload); err != nil {
+				return nil, err
+			}
+			results := map[string]interface{}{}
+			for _, item := range payload.Items {
+				batches = append(batches, fmt.Sprintf("%s:%s", item.RequestType, item.Key))
+				results[item.Key] = map[string]interface{}{"result": item.Key}
+			}
+			res, _ := json.Marshal(results)
+			return &lambda.InvokeOutput{Payload: res}, nil
+		}).AnyTimes()
+
+	config := generateResourceSetTestConfig(2, `a = jsonencode({ v = 1 })`)
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps: []resource.TestStep{
+			{
+				Config:             `provider "lambdabased" { dry_run = true }` + config,
+				ExpectNonEmptyPlan: true,
+			},
+			// Items applied in dry run mode are invoked for real once dry run is off
+			{
+				Config: config,
+				Check: func(s *terraform.State) error {
+					assert.Equal(t, []string{"create:a"}, batches)
+					rs := s.RootModule().Resources["lambdabased_resource_set.test"].Primary
+					assert.Equal(t, "a", rs.Attributes["results.a"])
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestLambdaBasedResourceSet_partialCreate(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	var batches []string
+	failing := map[string]bool{"b": true}
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			var payload struct{ Items []itemChange }
+			if err := json.Unmarshal(params.Payload, &payload); err != nil {
+				return nil, err
+			}
+			results := map[string]interface{}{}
+			for _, item := range payload.Items {
+				batches = append(batches, fmt.Sprintf("%s:%s", item.RequestType, item.Key))
+				if failing[item.Key] {
+					results[item.Key] = map[string]string{"error": "quota-exceeded"}
+				} else {
+					results[item.Key] = map[string]interface{}{"result": item.Key}
+				}
+			}
+			res, _ := json.Marshal(results)
+			return &lambda.InvokeOutput{Payload: res}, nil
+		}).AnyTimes()
+
+	config := generateResourceSetTestConfig(1, `a = jsonencode({ v = 1 }), b = jsonencode({ v = 1 })`)
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps: []resource.TestStep{
+			// The set isn't tainted, so the applied items are kept
+			{
+				Config:             config,
+				ExpectNonEmptyPlan: true,
+				Check: func(s *terraform.State) error {
+					assert.Equal(t, []string{"create:a", "create:b"}, batches)
+					rs := s.RootModule().Resources["lambdabased_resource_set.test"].Primary
+					assert.False(t, rs.Tainted)
+					assert.Equal(t, "a", rs.Attributes["results.a"])
+					assert.Empty(t, rs.Attributes["results.b"])
+					return nil
+				},
+			},
+			// Only the failed items are retried
+			{
+				PreConfig: func() { batches, failing = nil, map[string]bool{} },
+				Config:    config,
+				Check: func(s *terraform.State) error {
+					assert.Equal(t, []string{"create:b"}, batches)
+					return nil
+				},
+			},
+		},
+	})
+}
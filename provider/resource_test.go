@@ -343,6 +343,70 @@ func TestLambdaBasedResource_finalizerLambdaError(t *testing.T) {
 	})
 }
 
+func TestLambdaBasedResource_defaultInput(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	var steps []resource.TestStep
+
+	configParam := newConfigParameters()
+	configParam.DefaultInput = "default-input-val"
+
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, false)).Return(createLambdaInvokeOutput(false), nil)
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(configParam),
+		Check: func(s *terraform.State) error {
+			rs := getTestResourceState(s)
+			assert.Equal(t, getInputJson("createupdate-input-param-val"), rs.Attributes["input"]) // defaults are not stored
+			assert.Equal(t, "", rs.Attributes["default_input_hash"])
+			return nil
+		},
+	})
+
+	// Default input change doesn't trigger lambda because the resource doesn't track it
+	configParam.DefaultInput = "a-new-default-input-val"
+	steps = append(steps, resource.TestStep{
+		Config:             generateTestConfig(configParam),
+		PlanOnly:           true,
+		ExpectNonEmptyPlan: false,
+	})
+
+	// Opting in to track the default input, or toggling other plan-only settings, only stores them
+	configParam.TriggerOnDefaultInput = true
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(configParam),
+		Check: func(s *terraform.State) error {
+			rs := getTestResourceState(s)
+			assert.NotEqual(t, "", rs.Attributes["default_input_hash"])
+			return nil
+		},
+	})
+	configParam.ExtraAttributes = "verify_on_plan = false"
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(configParam),
+	})
+
+	// Now a default input change triggers lambda
+	configParam.DefaultInput = "yet-another-default-input-val"
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, false)).Return(createLambdaInvokeOutput(false), nil)
+	steps = append(steps, resource.TestStep{
+		Config: generateTestConfig(configParam),
+	})
+
+	// Finalizer receives the defaults as well
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, true)).Return(createLambdaInvokeOutput(false), nil)
+	steps = append(steps, resource.TestStep{
+		Config:  generateTestConfig(configParam),
+		Destroy: true,
+	})
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps:             steps,
+	})
+}
+
 //      .-.     .-.     .-.     .-.     .-.     .-.     .-.
 // `._.'   `._.'   `._.'   `._.'   `._.'   `._.'   `._.'   `._.'
 //
@@ -367,7 +431,7 @@ func createMockProviderFactories(lambdaClient LambdaClient) map[string]func() (*
 	return map[string]func() (*schema.Provider, error){
 		"lambdabased": func() (*schema.Provider, error) {
 			p := createProvider(func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-				return lambdaClient, nil
+				return newProviderMeta(d, lambdaClient)
 			})
 			raw := map[string]interface{}{"region": "us-east-1"}
 			err := p.Configure(context.Background(), terraform.NewResourceConfigRaw(raw))
@@ -382,6 +446,11 @@ func createMockProviderFactories(lambdaClient LambdaClient) map[string]func() (*
 func generateTestConfig(params configParameters) string {
 	t := template.New("LambdaBasedResourceTest")
 	t.Parse(`
+		{{if .DefaultInput}}
+		provider "lambdabased" {
+			default_input = "{\"default\":\"{{.DefaultInput}}\"}"
+		}
+		{{end}}
 		resource "lambdabased_resource" "test" {
 			function_name = "{{.FunctionName}}"
 			triggers = { trig_key = "{{.TriggerParameter}}" }
@@ -389,6 +458,8 @@ func generateTestConfig(params configParameters) string {
 			input = "{\"param\":\"{{.Input}}\"}"
 			conceal_input = {{.ConcealInput}}
 			conceal_result = {{.ConcealResult}}
+			trigger_on_default_input = {{.TriggerOnDefaultInput}}
+			{{.ExtraAttributes}}
 			{{if .FinalizerBlockOn}}
 			finalizer {
 				function_name = "{{.FinalizerFunctionName}}"
@@ -418,6 +489,10 @@ type configParameters struct {
 	ConcealInput     bool
 	ConcealResult    bool
 
+	DefaultInput          string
+	TriggerOnDefaultInput bool
+	ExtraAttributes       string
+
 	FinalizerBlockOn      bool
 	FinalizerFunctionName string
 	FinalizerInput        string
@@ -443,6 +518,13 @@ func getInputJson(param string) string {
 	return fmt.Sprintf("{\"param\":\"%s\"}", param)
 }
 
+func getPayloadJson(cp configParameters, param string) string {
+	if cp.DefaultInput == "" {
+		return getInputJson(param)
+	}
+	return fmt.Sprintf("{\"default\":\"%s\",\"param\":\"%s\"}", cp.DefaultInput, param)
+}
+
 func createLambdaInvokeInput(cp configParameters, forFinalizer bool) *lambda.InvokeInput {
 	ret := &lambda.InvokeInput{
 		InvocationType: lambdatypes.InvocationTypeRequestResponse,
@@ -451,12 +533,12 @@ func createLambdaInvokeInput(cp configParameters, forFinalizer bool) *lambda.Inv
 	if !forFinalizer {
 		ret.FunctionName = &cp.FunctionName
 		ret.Qualifier = &cp.Qualifier
-		ret.Payload = []byte(getInputJson(cp.Input))
+		ret.Payload = []byte(getPayloadJson(cp, cp.Input))
 	} else {
 		if cp.FinalizerBlockOn {
 			ret.FunctionName = &cp.FinalizerFunctionName
 			ret.Qualifier = &cp.FinalizerQualifier
-			ret.Payload = []byte(getInputJson(cp.FinalizerInput))
+			ret.Payload = []byte(getPayloadJson(cp, cp.FinalizerInput))
 		}
 	}
 	return ret
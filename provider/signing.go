@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/thetradedesk/terraform-provider-lambdabased/lambdabasedsig"
+)
+
+// headerClientContext carries the client context to the http and function url
+// backends, which have no other place for it.
+const headerClientContext = "X-Lambdabased-Client-Context"
+
+// signingKey signs the payloads of the invocations, see the lambdabasedsig
+// package for the verification.
+type signingKey struct {
+	key       []byte
+	keyID     string
+	algorithm string
+}
+
+// clientContext returns the client context carrying the signature of payload,
+// or nil if signing is not configured. The payload itself is not changed, so
+// recordings match signed invocations as well.
+func (k *signingKey) clientContext(payload []byte) (*string, error) {
+	if k == nil {
+		return nil, nil
+	}
+	signature, err := lambdabasedsig.Sign(k.key, k.keyID, k.algorithm, payload, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	ret, err := signature.ClientContext()
+	if err != nil {
+		return nil, err
+	}
+	return aws.String(ret), nil
+}
@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thetradedesk/terraform-provider-lambdabased/lambdabasedsig"
+)
+
+func TestCallLambda_signingQueued(t *testing.T) {
+	meta := &providerMeta{
+		signing: &signingKey{key: []byte("signing-secret"), keyID: "terraform", algorithm: lambdabasedsig.AlgorithmHMACSHA256},
+	}
+	params, released := invokeQueued(t, meta, map[string]interface{}{
+		"function_name": "queued-func",
+		"qualifier":     "$LATEST",
+		"input":         `{"name":"a"}`,
+	})
+
+	// The signature is made once the invocation is let through
+	custom, err := lambdabasedsig.ParseClientContext(aws.ToString(params.ClientContext))
+	assert.NoError(t, err)
+	s, err := lambdabasedsig.Parse(custom)
+	assert.NoError(t, err)
+	assert.False(t, s.Timestamp.Before(released.Truncate(time.Second)))
+}
+
+func TestLambdaBasedResource_signing(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+
+	keys := map[string][]byte{"terraform": []byte("signing-secret")}
+	var verified []string
+	m.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			custom, err := lambdabasedsig.ParseClientContext(aws.ToString(params.ClientContext))
+			if err != nil {
+				return nil, err
+			}
+			s, err := lambdabasedsig.Verify(custom, params.Payload, keys, time.Minute)
+			if err != nil {
+				return nil, err
+			}
+			assert.Equal(t, lambdabasedsig.AlgorithmHMACSHA512, s.Algorithm)
+			verified = append(verified, string(params.Payload))
+			return &lambda.InvokeOutput{Payload: []byte(`"ok"`)}, nil
+		}).AnyTimes()
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		CheckDestroy: func(*terraform.State) error {
+			assert.Equal(t, []string{`{"name":"a"}`, `{"name":"b"}`}, verified)
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					provider "lambdabased" {
+						signing {
+							key       = "signing-secret"
+							key_id    = "terraform"
+							algorithm = "HMAC-SHA512"
+						}
+					}
+					resource "lambdabased_resource" "test" {
+						function_name = "signed"
+						input = jsonencode({ name = "a" })
+						finalizer {
+							function_name = "signed"
+							input = jsonencode({ name = "b" })
+						}
+					}`,
+				Check: resource.TestCheckResourceAttr("lambdabased_resource.test", "result", `"ok"`),
+			},
+		},
+	})
+}
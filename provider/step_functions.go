@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfntypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+)
+
+// StepFunctionsClient is the subset of the Step Functions API used to run
+// state machines and wait for their result. *sfn.Client implements it.
+type StepFunctionsClient interface {
+	StartExecution(ctx context.Context, params *sfn.StartExecutionInput, optFns ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error)
+	DescribeExecution(ctx context.Context, params *sfn.DescribeExecutionInput, optFns ...func(*sfn.Options)) (*sfn.DescribeExecutionOutput, error)
+	GetExecutionHistory(ctx context.Context, params *sfn.GetExecutionHistoryInput, optFns ...func(*sfn.Options)) (*sfn.GetExecutionHistoryOutput, error)
+}
+
+// stepFunctionsBackend runs a state machine, whose ARN is passed in
+// InvokeInput.FunctionName, and waits for the execution to finish. Executions
+// that don't succeed are reported as function errors carrying their error and cause.
+type stepFunctionsBackend struct {
+	client       StepFunctionsClient
+	pollInterval time.Duration
+}
+
+func newStepFunctionsBackend(client StepFunctionsClient) *stepFunctionsBackend {
+	return &stepFunctionsBackend{client: client, pollInterval: 5 * time.Second}
+}
+
+func (b *stepFunctionsBackend) Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	execution, err := b.client.StartExecution(ctx, &sfn.StartExecutionInput{
+		StateMachineArn: params.FunctionName,
+		Input:           aws.String(string(params.Payload)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] started execution %s\n", aws.ToString(execution.ExecutionArn))
+
+	for {
+		res, err := b.client.DescribeExecution(ctx, &sfn.DescribeExecutionInput{
+			ExecutionArn: execution.ExecutionArn,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("describing execution %s: %w", aws.ToString(execution.ExecutionArn), err)
+		}
+
+		switch res.Status {
+		case sfntypes.ExecutionStatusSucceeded:
+			return &lambda.InvokeOutput{StatusCode: 200, Payload: []byte(aws.ToString(res.Output))}, nil
+		case sfntypes.ExecutionStatusFailed, sfntypes.ExecutionStatusTimedOut, sfntypes.ExecutionStatusAborted:
+			errorCode, cause := b.executionError(ctx, execution.ExecutionArn)
+			return &lambda.InvokeOutput{
+				StatusCode:    200,
+				FunctionError: aws.String(string(res.Status)),
+				Payload: []byte(fmt.Sprintf("execution %s %s, error: %s, cause: %s",
+					aws.ToString(execution.ExecutionArn), res.Status, errorCode, cause)),
+			}, nil
+		}
+
+		select {
+		case <-time.After(b.pollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// executionError looks up the error and the cause of a finished execution from
+// its last history event. Lookup failures are only logged since the execution
+// status is already known.
+func (b *stepFunctionsBackend) executionError(ctx context.Context, executionArn *string) (string, string) {
+	history, err := b.client.GetExecutionHistory(ctx, &sfn.GetExecutionHistoryInput{
+		ExecutionArn: executionArn,
+		MaxResults:   1,
+		ReverseOrder: true,
+	})
+	if err != nil || len(history.Events) == 0 {
+		log.Printf("[WARN] couldn't get the history of execution %s: %v\n", aws.ToString(executionArn), err)
+		return "", ""
+	}
+
+	event := history.Events[0]
+	switch {
+	case event.ExecutionFailedEventDetails != nil:
+		return aws.ToString(event.ExecutionFailedEventDetails.Error), aws.ToString(event.ExecutionFailedEventDetails.Cause)
+	case event.ExecutionTimedOutEventDetails != nil:
+		return aws.ToString(event.ExecutionTimedOutEventDetails.Error), aws.ToString(event.ExecutionTimedOutEventDetails.Cause)
+	case event.ExecutionAbortedEventDetails != nil:
+		return aws.ToString(event.ExecutionAbortedEventDetails.Error), aws.ToString(event.ExecutionAbortedEventDetails.Cause)
+	}
+	return "", ""
+}
@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfntypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/thetradedesk/terraform-provider-lambdabased/provider/mocks"
+)
+
+const (
+	testStateMachineArn = "arn:aws:states:us-east-1:123456789012:stateMachine:bootstrap"
+	testExecutionArn    = "arn:aws:states:us-east-1:123456789012:execution:bootstrap:1"
+)
+
+func TestLambdaBasedResource_stateMachine(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	m := mocks.NewMockStepFunctionsClient(c)
+	var steps []resource.TestStep
+
+	config := func(trigger string) string {
+		return `
+		resource "lambdabased_resource" "test" {
+			state_machine_arn = "` + testStateMachineArn + `"
+			triggers = { trig_key = "` + trigger + `" }
+			input = jsonencode({ param = "createupdate-input-param-val" })
+			conceal_input = true
+		}`
+	}
+
+	// Polls until the execution succeeds
+	gomock.InOrder(
+		m.EXPECT().StartExecution(gomock.Any(), &sfn.StartExecutionInput{
+			StateMachineArn: aws.String(testStateMachineArn),
+			Input:           aws.String(getInputJson("createupdate-input-param-val")),
+		}).Return(&sfn.StartExecutionOutput{ExecutionArn: aws.String(testExecutionArn)}, nil),
+		m.EXPECT().DescribeExecution(gomock.Any(), gomock.Any()).Return(&sfn.DescribeExecutionOutput{Status: sfntypes.ExecutionStatusRunning}, nil),
+		m.EXPECT().DescribeExecution(gomock.Any(), gomock.Any()).Return(&sfn.DescribeExecutionOutput{
+			Status: sfntypes.ExecutionStatusSucceeded,
+			Output: aws.String("result-val"),
+		}, nil),
+	)
+	steps = append(steps, resource.TestStep{
+		Config: config("trigger-param-val"),
+		Check: func(s *terraform.State) error {
+			rs := getTestResourceState(s)
+			assert.Equal(t, "", rs.Attributes["input"]) // input is concealed
+			assert.Equal(t, "result-val", rs.Attributes["result"])
+			return nil
+		},
+	})
+
+	// A failed execution is reported with its error and cause
+	gomock.InOrder(
+		m.EXPECT().StartExecution(gomock.Any(), gomock.Any()).Return(&sfn.StartExecutionOutput{ExecutionArn: aws.String(testExecutionArn)}, nil),
+		m.EXPECT().DescribeExecution(gomock.Any(), gomock.Any()).Return(&sfn.DescribeExecutionOutput{Status: sfntypes.ExecutionStatusFailed}, nil),
+		m.EXPECT().GetExecutionHistory(gomock.Any(), &sfn.GetExecutionHistoryInput{
+			ExecutionArn: aws.String(testExecutionArn),
+			MaxResults:   1,
+			ReverseOrder: true,
+		}).Return(&sfn.GetExecutionHistoryOutput{
+			Events: []sfntypes.HistoryEvent{{
+				ExecutionFailedEventDetails: &sfntypes.ExecutionFailedEventDetails{
+					Error: aws.String("States.TaskFailed"),
+					Cause: aws.String("addon-install-failed"),
+				},
+			}},
+		}, nil),
+	)
+	steps = append(steps, resource.TestStep{
+		Config:      config("trigger-now"),
+		ExpectError: regexp.MustCompile(`FAILED, error: States.TaskFailed, cause: addon-install-failed`),
+	})
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest: true,
+		ProviderFactories: map[string]func() (*schema.Provider, error){
+			"lambdabased": func() (*schema.Provider, error) {
+				return createProvider(func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+					meta, diags := newProviderMeta(d, nil)
+					if meta != nil {
+						meta.stepFunctionsClient = &stepFunctionsBackend{client: m}
+					}
+					return meta, diags
+				}), nil
+			},
+		},
+		Steps: steps,
+	})
+}
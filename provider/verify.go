@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// verifyOnPlan checks the permissions and the parameters of the main function
+// and the functions of the finalizer, pre_update, post_update and rollback
+// blocks with DryRun invocations. Function urls, state machines and values
+// unknown during plan are not verified.
+func verifyOnPlan(ctx context.Context, d *schema.ResourceDiff, meta *providerMeta) error {
+	verify := meta.verifyOnPlan
+	if raw := d.GetRawConfig().GetAttr("verify_on_plan"); !raw.IsNull() && raw.IsKnown() {
+		verify = raw.True()
+	}
+	if !verify || meta.dryRun {
+		return nil
+	}
+
+	if d.NewValueKnown("function_name") && d.NewValueKnown("qualifier") {
+		data := map[string]interface{}{
+			"function_name": d.Get("function_name"),
+			"qualifier":     d.Get("qualifier"),
+		}
+		if err := verifyFunction(ctx, data, meta); err != nil {
+			return fmt.Errorf("verifying function_name: %w", err)
+		}
+	}
+
+	for _, name := range invocationBlocks {
+		if !d.NewValueKnown(name) {
+			continue
+		}
+		for i, block := range d.Get(name).([]interface{}) {
+			if err := verifyFunction(ctx, block.(map[string]interface{}), meta); err != nil {
+				return fmt.Errorf("verifying %s.%d.function_name: %w", name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func verifyFunction(ctx context.Context, data map[string]interface{}, meta *providerMeta) error {
+	functionName, _ := data["function_name"].(string)
+	if functionName == "" {
+		return nil
+	}
+
+	_, conn, err := meta.invocationTarget(ctx, data)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Invoke(ctx, &lambda.InvokeInput{
+		FunctionName:   aws.String(functionName),
+		InvocationType: lambdatypes.InvocationTypeDryRun,
+		Qualifier:      aws.String(data["qualifier"].(string)),
+	})
+	if err != nil {
+		return fmt.Errorf("DryRun invocation of %s (qualifier: %s) failed: %w", functionName, data["qualifier"], err)
+	}
+	return nil
+}
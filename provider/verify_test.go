@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestLambdaBasedResource_verifyOnPlan(t *testing.T) {
+	m, c := createMockLambdaClient(t)
+	defer c.Finish()
+	var steps []resource.TestStep
+
+	configParam := newConfigParameters()
+	config := func(extraAttributes string) string {
+		cp := configParam
+		cp.ExtraAttributes = extraAttributes
+		return `
+		provider "lambdabased" {
+			verify_on_plan = true
+		}` + generateTestConfig(cp)
+	}
+
+	finalizerAllowed := true
+	m.EXPECT().Invoke(gomock.Any(), createLambdaDryRunInput(configParam.FunctionName, configParam.Qualifier)).Return(&lambda.InvokeOutput{StatusCode: 204}, nil).AnyTimes()
+	m.EXPECT().Invoke(gomock.Any(), createLambdaDryRunInput(configParam.FinalizerFunctionName, configParam.FinalizerQualifier)).DoAndReturn(
+		func(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+			if !finalizerAllowed {
+				return nil, &lambdatypes.ResourceNotFoundException{Message: aws.String("this-error-is-expected")}
+			}
+			return &lambda.InvokeOutput{StatusCode: 204}, nil
+		}).AnyTimes()
+
+	// Verified during plan, invoked during apply
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, false)).Return(createLambdaInvokeOutput(false), nil)
+	steps = append(steps, resource.TestStep{
+		Config: config(""),
+	})
+
+	// Failing verification fails the plan
+	steps = append(steps, resource.TestStep{
+		PreConfig:   func() { finalizerAllowed = false },
+		Config:      config(""),
+		PlanOnly:    true,
+		ExpectError: regexp.MustCompile(`verifying finalizer.0.function_name: DryRun invocation of func-destroy-name-1`),
+	})
+
+	// Unless the resource opts out
+	steps = append(steps, resource.TestStep{
+		Config:             config("verify_on_plan = false"),
+		PlanOnly:           true,
+		ExpectNonEmptyPlan: false,
+	})
+
+	m.EXPECT().Invoke(gomock.Any(), createLambdaInvokeInput(configParam, true)).Return(createLambdaInvokeOutput(false), nil)
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:        true,
+		PreCheck:          preCheck,
+		ProviderFactories: createMockProviderFactories(m),
+		Steps:             steps,
+	})
+}
+
+func createLambdaDryRunInput(functionName, qualifier string) *lambda.InvokeInput {
+	return &lambda.InvokeInput{
+		FunctionName:   aws.String(functionName),
+		InvocationType: lambdatypes.InvocationTypeDryRun,
+		Qualifier:      aws.String(qualifier),
+	}
+}